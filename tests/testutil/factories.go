@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -75,10 +76,12 @@ func (b *OrderBuilder) WithETA(minutes int) *OrderBuilder {
 	return b
 }
 
+// WithContact adds a contact; the restaurant is recognised by its name.
 func (b *OrderBuilder) WithContact(name, phone string) *OrderBuilder {
-	b.order.Contacts = []tracker.Contact{
-		{Title: name},
-	}
+	b.order.Contacts = append(b.order.Contacts, tracker.Contact{
+		Title:                name,
+		FormattedPhoneNumber: phone,
+	})
 	return b
 }
 
@@ -117,6 +120,26 @@ func (b *OrderBuilder) WithTotal(total string) *OrderBuilder {
 	return b
 }
 
+// WithSummary sets the full payment summary (total, fees, tip…).
+func (b *OrderBuilder) WithSummary(summary tracker.OrderSummary) *OrderBuilder {
+	if len(b.order.FeedCards) > 0 {
+		b.order.FeedCards[0].OrderSummary = summary
+	}
+	return b
+}
+
+// WithCourierProfile sets the courier profile, keeping the PIN if any.
+func (b *OrderBuilder) WithCourierProfile(profile tracker.CourierInfo) *OrderBuilder {
+	if len(b.order.FeedCards) > 0 {
+		fc := &b.order.FeedCards[0]
+		if len(fc.Courier) > 0 {
+			profile.PinInfo = fc.Courier[0].PinInfo
+		}
+		fc.Courier = []tracker.CourierInfo{profile}
+	}
+	return b
+}
+
 // WithAddress sets the delivery address.
 func (b *OrderBuilder) WithAddress(addr string) *OrderBuilder {
 	if len(b.order.FeedCards) > 0 {
@@ -132,12 +155,42 @@ func (b *OrderBuilder) Build() tracker.Order {
 	return b.order
 }
 
+// WithUUID définit l'UUID de l'order (utile pour les réponses multi-commandes).
+func (b *OrderBuilder) WithUUID(uuid string) *OrderBuilder {
+	b.order.UUID = uuid
+	return b
+}
+
 // WithPhase définit la phase de l'order (ex: "ACTIVE", "COMPLETED", "DELIVERED").
 func (b *OrderBuilder) WithPhase(phase string) *OrderBuilder {
 	b.order.OrderStatus.OrderPhase = phase
 	return b
 }
 
+// WithCourierPosition ajoute l'entité carte du livreur aux coordonnées données.
+func (b *OrderBuilder) WithCourierPosition(lat, lon float64) *OrderBuilder {
+	if len(b.order.BackgroundFeedCards) == 0 {
+		b.order.BackgroundFeedCards = []tracker.BackgroundFeedCard{{}}
+	}
+	card := &b.order.BackgroundFeedCards[0]
+	card.MapEntity = append(card.MapEntity, tracker.MapEntity{
+		UUID: "courier", Type: "COURIER", Latitude: lat, Longitude: lon,
+	})
+	return b
+}
+
+// WithRestaurantPosition adds the restaurant map entity at the given coordinates.
+func (b *OrderBuilder) WithRestaurantPosition(lat, lon float64) *OrderBuilder {
+	if len(b.order.BackgroundFeedCards) == 0 {
+		b.order.BackgroundFeedCards = []tracker.BackgroundFeedCard{{}}
+	}
+	card := &b.order.BackgroundFeedCards[0]
+	card.MapEntity = append(card.MapEntity, tracker.MapEntity{
+		UUID: "restaurant", Type: "RESTAURANT", Latitude: lat, Longitude: lon,
+	})
+	return b
+}
+
 // BuildResponse wraps l'Order dans une Response complète.
 func (b *OrderBuilder) BuildResponse() tracker.Response {
 	return tracker.Response{
@@ -167,3 +220,23 @@ func LoadTestJSON(t testing.TB, filename string) []byte {
 	}
 	return data
 }
+
+// LoadTestResponse lit et désérialise une fixture JSON de tracker/testdata/.
+func LoadTestResponse(t testing.TB, filename string) tracker.Response {
+	t.Helper()
+	var resp tracker.Response
+	if err := json.Unmarshal(LoadTestJSON(t, filename), &resp); err != nil {
+		t.Fatalf("LoadTestResponse(%s): %v", filename, err)
+	}
+	return resp
+}
+
+// LoadTestCassette lit une cassette (JSON Lines) depuis tracker/testdata/.
+func LoadTestCassette(t testing.TB, filename string) []tracker.Interaction {
+	t.Helper()
+	interactions, err := tracker.LoadCassette(filepath.Join(testdataDir(), filename))
+	if err != nil {
+		t.Fatalf("LoadTestCassette(%s): %v", filename, err)
+	}
+	return interactions
+}
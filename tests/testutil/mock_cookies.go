@@ -0,0 +1,75 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/superselle/ubertracker/tracker"
+)
+
+// ══════════════════════════════════════════════════════════════
+// MockCookieProvider — Simule un CookieProvider sans navigateur
+// ══════════════════════════════════════════════════════════════
+
+// Vérification compile-time : MockCookieProvider satisfait tracker.CookieProvider.
+var _ tracker.CookieProvider = (*MockCookieProvider)(nil)
+
+// MockCookieProvider retourne des cookies fixes et une file de valeurs pour Refresh.
+type MockCookieProvider struct {
+	mu          sync.Mutex
+	current     *tracker.CookieJar
+	refreshes   []string
+	RefreshErr  error
+	Refreshed   int
+	Invalidated int
+}
+
+// NewMockCookieProvider crée un mock dont Get retourne initial et dont les
+// Refresh successifs retournent refreshes dans l'ordre (format header Cookie).
+func NewMockCookieProvider(initial string, refreshes ...string) *MockCookieProvider {
+	return &MockCookieProvider{current: tracker.ParseCookieHeader(initial), refreshes: refreshes}
+}
+
+func (m *MockCookieProvider) Get(_ context.Context) (*tracker.CookieJar, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current, nil
+}
+
+func (m *MockCookieProvider) Refresh(_ context.Context, _ string) (*tracker.CookieJar, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Refreshed++
+
+	if m.RefreshErr != nil {
+		return nil, m.RefreshErr
+	}
+	if len(m.refreshes) == 0 {
+		return nil, fmt.Errorf("MockCookieProvider: no more queued refreshes (call #%d)", m.Refreshed)
+	}
+	m.current = tracker.ParseCookieHeader(m.refreshes[0])
+	m.refreshes = m.refreshes[1:]
+	return m.current, nil
+}
+
+func (m *MockCookieProvider) Invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Invalidated++
+	m.current = tracker.NewCookieJar()
+}
+
+// InvalidateCount retourne le nombre d'appels à Invalidate.
+func (m *MockCookieProvider) InvalidateCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Invalidated
+}
+
+// RefreshCount retourne le nombre d'appels à Refresh.
+func (m *MockCookieProvider) RefreshCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Refreshed
+}
@@ -13,8 +13,14 @@ import (
 // MockOrderStore — Implémentation in-memory de tracker.OrderStore
 // ══════════════════════════════════════════════════════════════
 
-// Vérification compile-time : MockOrderStore satisfait tracker.OrderStore.
-var _ tracker.OrderStore = (*MockOrderStore)(nil)
+// Vérification compile-time : MockOrderStore satisfait tracker.OrderStore et
+// les interfaces optionnelles (PositionStore, TimelineStore, HistoryStore).
+var (
+	_ tracker.OrderStore    = (*MockOrderStore)(nil)
+	_ tracker.PositionStore = (*MockOrderStore)(nil)
+	_ tracker.TimelineStore = (*MockOrderStore)(nil)
+	_ tracker.HistoryStore  = (*MockOrderStore)(nil)
+)
 
 type snapshotEntry struct {
 	Status   string
@@ -29,11 +35,16 @@ type MockOrderStore struct {
 	snapshots map[string]snapshotEntry
 	orders    map[string]tracker.TrackedOrder
 	messages  map[string]string
+	trails    map[string][]tracker.Position
+	timelines map[string]tracker.Timeline
+	history   map[string][]tracker.HistoryEntry
 
 	// SaveErr provoque une erreur au prochain SaveOrder si non-nil.
 	SaveErr error
 	// SnapshotErr provoque une erreur au prochain GetSnapshot si non-nil.
 	SnapshotErr error
+	// AppendErr provoque une erreur au prochain AppendPatch si non-nil.
+	AppendErr error
 }
 
 // NewMockOrderStore crée un MockOrderStore vide prêt à l'emploi.
@@ -42,6 +53,9 @@ func NewMockOrderStore() *MockOrderStore {
 		snapshots: make(map[string]snapshotEntry),
 		orders:    make(map[string]tracker.TrackedOrder),
 		messages:  make(map[string]string),
+		trails:    make(map[string][]tracker.Position),
+		timelines: make(map[string]tracker.Timeline),
+		history:   make(map[string][]tracker.HistoryEntry),
 	}
 }
 
@@ -97,12 +111,10 @@ func (m *MockOrderStore) GetPendingOrders(_ context.Context) (map[string]int, er
 
 	pending := make(map[string]int)
 	for uuid, order := range m.orders {
-		switch order.LastStatus {
-		case "COMPLETED", "DELIVERED", "CANCELLED", "FAILED":
+		if tracker.OrderPhase(order.LastStatus).IsTerminal() {
 			continue
-		default:
-			pending[uuid] = order.LastProgress
 		}
+		pending[uuid] = order.LastProgress
 	}
 	return pending, nil
 }
@@ -113,22 +125,64 @@ func (m *MockOrderStore) ListResumableOrders(_ context.Context) ([]tracker.Resum
 
 	var resumable []tracker.ResumableOrder
 	for _, order := range m.orders {
-		switch order.LastStatus {
-		case "COMPLETED", "DELIVERED", "CANCELLED", "FAILED":
+		if tracker.OrderPhase(order.LastStatus).IsTerminal() {
 			continue
-		default:
-			resumable = append(resumable, tracker.ResumableOrder{
-				UUID:      order.UUID,
-				ChannelID: order.ChannelID,
-				GuildID:   order.GuildID,
-				ClientID:  order.ClientID,
-				CuistotID: order.CuistotID,
-			})
 		}
+		resumable = append(resumable, tracker.ResumableOrder{
+			UUID:      order.UUID,
+			ChannelID: order.ChannelID,
+			GuildID:   order.GuildID,
+			ClientID:  order.ClientID,
+			CuistotID: order.CuistotID,
+		})
 	}
 	return resumable, nil
 }
 
+func (m *MockOrderStore) AppendCourierPosition(_ context.Context, uuid string, p tracker.Position) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.trails[uuid] = append(m.trails[uuid], p)
+	return nil
+}
+
+func (m *MockOrderStore) GetCourierTrail(_ context.Context, uuid string) ([]tracker.Position, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]tracker.Position(nil), m.trails[uuid]...), nil
+}
+
+func (m *MockOrderStore) SaveTimeline(_ context.Context, uuid string, t tracker.Timeline) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timelines[uuid] = append(tracker.Timeline(nil), t...)
+	return nil
+}
+
+func (m *MockOrderStore) GetTimeline(_ context.Context, uuid string) (tracker.Timeline, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append(tracker.Timeline(nil), m.timelines[uuid]...), nil
+}
+
+func (m *MockOrderStore) AppendPatch(_ context.Context, uuid string, e tracker.HistoryEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.AppendErr != nil {
+		err := m.AppendErr
+		m.AppendErr = nil
+		return err
+	}
+	m.history[uuid] = append(m.history[uuid], e)
+	return nil
+}
+
+func (m *MockOrderStore) GetHistory(_ context.Context, uuid string) ([]tracker.HistoryEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]tracker.HistoryEntry(nil), m.history[uuid]...), nil
+}
+
 // ── Méthodes utilitaires pour les assertions ──
 
 // GetOrder retourne la dernière version sauvée d'une commande.
@@ -153,8 +207,12 @@ func (m *MockOrderStore) Reset() {
 	m.snapshots = make(map[string]snapshotEntry)
 	m.orders = make(map[string]tracker.TrackedOrder)
 	m.messages = make(map[string]string)
+	m.trails = make(map[string][]tracker.Position)
+	m.timelines = make(map[string]tracker.Timeline)
+	m.history = make(map[string][]tracker.HistoryEntry)
 	m.SaveErr = nil
 	m.SnapshotErr = nil
+	m.AppendErr = nil
 }
 
 // SeedSnapshot injects a previous snapshot to simulate an existing order in store.
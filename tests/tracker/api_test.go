@@ -0,0 +1,146 @@
+// Package tracker_test — Tests Black Box pour le package tracker (client API).
+package tracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tracker"
+)
+
+// capturedRequest mémorise ce que le serveur de test a reçu.
+type capturedRequest struct {
+	Path    string
+	Locale  string
+	Cookie  string
+	Header  http.Header
+	Payload tracker.TrackingPayload
+}
+
+// newEchoServer démarre un serveur qui capture la requête et répond status/body.
+func newEchoServer(t *testing.T, status int, body string) (*httptest.Server, chan capturedRequest) {
+	t.Helper()
+	captured := make(chan capturedRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var p tracker.TrackingPayload
+		_ = json.Unmarshal(raw, &p)
+		captured <- capturedRequest{
+			Path:    r.URL.Path,
+			Locale:  r.URL.Query().Get("localeCode"),
+			Cookie:  r.Header.Get("Cookie"),
+			Header:  r.Header.Clone(),
+			Payload: p,
+		}
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, captured
+}
+
+// ══════════════════════════════════════════════════════════════
+// Client — configuration
+// ══════════════════════════════════════════════════════════════
+
+func TestClient_Fetch_UsesConfiguration(t *testing.T) {
+	srv, captured := newEchoServer(t, 200, `{"data":{"orders":[]}}`)
+
+	client := tracker.NewClient(
+		tracker.WithBaseURL(srv.URL),
+		tracker.WithLocale("en"),
+		tracker.WithTimezone("America/New_York"),
+		tracker.WithCookies("sid=abc"),
+		tracker.WithHeader("x-test", "1"),
+		tracker.WithTimeout(5*time.Second),
+	)
+
+	body, err := client.Fetch(context.Background(), "uuid-1")
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if string(body) != `{"data":{"orders":[]}}` {
+		t.Errorf("body = %q", body)
+	}
+
+	req := <-captured
+	if req.Path != "/_p/api/getActiveOrdersV1" {
+		t.Errorf("path = %q", req.Path)
+	}
+	if req.Locale != "en" {
+		t.Errorf("localeCode = %q, want en", req.Locale)
+	}
+	if req.Payload.Timezone != "America/New_York" {
+		t.Errorf("timezone = %q, want America/New_York", req.Payload.Timezone)
+	}
+	if req.Payload.OrderUUID != "uuid-1" {
+		t.Errorf("orderUuid = %q, want uuid-1", req.Payload.OrderUUID)
+	}
+	if req.Cookie != "sid=abc" {
+		t.Errorf("Cookie = %q, want sid=abc", req.Cookie)
+	}
+	if req.Header.Get("x-test") != "1" {
+		t.Errorf("x-test header = %q, want 1", req.Header.Get("x-test"))
+	}
+}
+
+func TestClient_Fetch_DefaultsMatchHistoricalBehaviour(t *testing.T) {
+	srv, captured := newEchoServer(t, 200, `{}`)
+
+	client := tracker.NewClient(tracker.WithBaseURL(srv.URL))
+	if _, err := client.Fetch(context.Background(), "uuid-1"); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+
+	req := <-captured
+	if req.Locale != "fr" {
+		t.Errorf("localeCode = %q, want fr", req.Locale)
+	}
+	if req.Payload.Timezone != "Europe/Paris" {
+		t.Errorf("timezone = %q, want Europe/Paris", req.Payload.Timezone)
+	}
+}
+
+func TestClient_Fetch_HTTPError(t *testing.T) {
+	srv, _ := newEchoServer(t, 500, `oops`)
+
+	client := tracker.NewClient(tracker.WithBaseURL(srv.URL))
+	if _, err := client.Fetch(context.Background(), "uuid-1"); err == nil {
+		t.Fatal("Fetch should fail on HTTP 500")
+	}
+}
+
+func TestClient_IndependentInstances(t *testing.T) {
+	srvA, capA := newEchoServer(t, 200, `{}`)
+	srvB, capB := newEchoServer(t, 200, `{}`)
+
+	a := tracker.NewClient(tracker.WithBaseURL(srvA.URL), tracker.WithCookies("a=1"))
+	b := tracker.NewClient(tracker.WithBaseURL(srvB.URL), tracker.WithCookies("b=2"))
+
+	if _, err := a.Fetch(context.Background(), "uuid-a"); err != nil {
+		t.Fatalf("client A: %v", err)
+	}
+	if _, err := b.Fetch(context.Background(), "uuid-b"); err != nil {
+		t.Fatalf("client B: %v", err)
+	}
+
+	if got := (<-capA).Cookie; got != "a=1" {
+		t.Errorf("client A cookie = %q, want a=1", got)
+	}
+	if got := (<-capB).Cookie; got != "b=2" {
+		t.Errorf("client B cookie = %q, want b=2", got)
+	}
+}
+
+func TestClient_Fetch_WorksAsManagerFetchFn(t *testing.T) {
+	client := tracker.NewClient()
+	mgr := tracker.NewManager(nil, client.Fetch)
+	if mgr == nil {
+		t.Fatal("NewManager returned nil")
+	}
+}
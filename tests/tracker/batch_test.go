@@ -0,0 +1,311 @@
+// Package tracker_test — Tests Black Box pour le package tracker (polling groupé).
+package tracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+// fakeBatchSession simule getActiveOrdersV1 : chaque appel retourne toutes
+// les commandes actives de la session (phase par UUID), quelle que soit la
+// demande, avec une clé inconnue des modèles.
+type fakeBatchSession struct {
+	mu     sync.Mutex
+	phases map[string]string
+	calls  atomic.Int32
+	asked  [][]string
+}
+
+func newFakeBatchSession(phases map[string]string) *fakeBatchSession {
+	return &fakeBatchSession{phases: phases}
+}
+
+func (f *fakeBatchSession) Fn(_ context.Context, uuids []string) ([]byte, error) {
+	f.calls.Add(1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.asked = append(f.asked, uuids)
+
+	orders := []map[string]any{}
+	for uuid, phase := range f.phases {
+		raw, _ := json.Marshal(testutil.NewTestOrder().WithUUID(uuid).WithPhase(phase).Build())
+		var order map[string]any
+		_ = json.Unmarshal(raw, &order)
+		order["riskFlags"] = []string{}
+		orders = append(orders, order)
+	}
+	return json.Marshal(map[string]any{"data": map[string]any{"orders": orders}})
+}
+
+// singleOrder décode une réponse à une seule commande : son identifiant et sa phase.
+func singleOrder(t *testing.T, data []byte) (id, phase string) {
+	t.Helper()
+	var resp struct {
+		Data struct {
+			Orders []struct {
+				UUID      string            `json:"uuid"`
+				OrderInfo tracker.OrderInfo `json:"orderInfo"`
+			} `json:"orders"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(resp.Data.Orders) != 1 {
+		t.Fatalf("orders = %d, want 1", len(resp.Data.Orders))
+	}
+	return resp.Data.Orders[0].UUID, resp.Data.Orders[0].OrderInfo.OrderPhase
+}
+
+// newPoller crée un poller avec la fenêtre de regroupement window.
+func newPoller(window time.Duration, sessions []tracker.BatchFetchFn, opts ...tracker.BatchOption) *tracker.BatchPoller {
+	return tracker.NewBatchPoller(sessions, append([]tracker.BatchOption{tracker.WithBatchWindow(window)}, opts...)...)
+}
+
+// fetchConcurrently lance un Fetch par UUID en parallèle et retourne les résultats.
+func fetchConcurrently(t *testing.T, p *tracker.BatchPoller, uuids ...string) map[string][]byte {
+	t.Helper()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	out := make(map[string][]byte)
+	for _, uuid := range uuids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := p.Fetch(context.Background(), uuid)
+			if err != nil {
+				t.Errorf("Fetch(%s): %v", uuid, err)
+				return
+			}
+			mu.Lock()
+			out[uuid] = data
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+// ══════════════════════════════════════════════════════════════
+// BatchPoller
+// ══════════════════════════════════════════════════════════════
+
+func TestBatchPoller_CoalescesAndDemultiplexes(t *testing.T) {
+	session := newFakeBatchSession(map[string]string{"a": "ACTIVE", "b": "PREPARING", "c": "COMPLETED"})
+	p := newPoller(50*time.Millisecond, []tracker.BatchFetchFn{session.Fn})
+
+	results := fetchConcurrently(t, p, "a", "b", "c")
+
+	if got := session.calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+	for uuid, phase := range map[string]string{"a": "ACTIVE", "b": "PREPARING", "c": "COMPLETED"} {
+		if id, got := singleOrder(t, results[uuid]); id != uuid || got != phase {
+			t.Errorf("%s: got uuid=%s phase=%s, want phase %s", uuid, id, got, phase)
+		}
+		// Les clés inconnues des modèles restent visibles de ValidateSchema.
+		if r := tracker.ValidateSchema(results[uuid], uuid); !slices.Contains(r.Unknown, "orders[].riskFlags") {
+			t.Errorf("%s: Unknown = %v, want orders[].riskFlags kept", uuid, r.Unknown)
+		}
+	}
+}
+
+func TestBatchPoller_CancelledWaiterStopsPendingBatch(t *testing.T) {
+	session := newFakeBatchSession(map[string]string{"a": "ACTIVE"})
+	p := newPoller(200*time.Millisecond, []tracker.BatchFetchFn{session.Fn})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := p.Fetch(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Fetch error = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Fetch returned after %v, want before the window ends", elapsed)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if got := session.calls.Load(); got != 0 {
+		t.Errorf("calls = %d, want 0 once every waiter left", got)
+	}
+
+	// Un nouveau demandeur ouvre un nouvel appel.
+	if _, err := p.Fetch(context.Background(), "a"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if got := session.calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestBatchPoller_ServesUnrequestedOrdersFromCache(t *testing.T) {
+	session := newFakeBatchSession(map[string]string{"a": "ACTIVE", "b": "ACTIVE"})
+	p := newPoller(10*time.Millisecond, []tracker.BatchFetchFn{session.Fn})
+
+	fetchConcurrently(t, p, "a", "b") // rattache a et b à la session
+	fetchConcurrently(t, p, "a")      // rafraîchit a ET b
+	if got := session.calls.Load(); got != 2 {
+		t.Fatalf("calls = %d, want 2", got)
+	}
+
+	data, err := p.Fetch(context.Background(), "b")
+	if err != nil {
+		t.Fatalf("Fetch(b): %v", err)
+	}
+	if id, _ := singleOrder(t, data); id != "b" {
+		t.Error("cached response should contain order b")
+	}
+	if got := session.calls.Load(); got != 2 {
+		t.Errorf("calls = %d, want 2 (b served from cache)", got)
+	}
+
+	// Le cache est consommé : le fetch suivant refait un appel.
+	if _, err := p.Fetch(context.Background(), "b"); err != nil {
+		t.Fatalf("Fetch(b): %v", err)
+	}
+	if got := session.calls.Load(); got != 3 {
+		t.Errorf("calls = %d, want 3", got)
+	}
+}
+
+func TestBatchPoller_ExpiredCacheIsRefetched(t *testing.T) {
+	session := newFakeBatchSession(map[string]string{"a": "ACTIVE", "b": "ACTIVE"})
+	p := newPoller(10*time.Millisecond, []tracker.BatchFetchFn{session.Fn}, tracker.WithBatchTTL(time.Nanosecond))
+
+	fetchConcurrently(t, p, "a", "b")
+	fetchConcurrently(t, p, "a")
+	fetchConcurrently(t, p, "b")
+	if got := session.calls.Load(); got != 3 {
+		t.Errorf("calls = %d, want 3 (cache expired)", got)
+	}
+}
+
+func TestBatchPoller_MissingOrderFallsBackToSingleFetch(t *testing.T) {
+	session := newFakeBatchSession(map[string]string{"a": "ACTIVE"})
+	p := newPoller(50*time.Millisecond, []tracker.BatchFetchFn{session.Fn})
+
+	fetchConcurrently(t, p, "a", "ghost")
+
+	if got := session.calls.Load(); got != 2 {
+		t.Fatalf("calls = %d, want 2 (batch + dedicated call)", got)
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if last := session.asked[1]; len(last) != 1 || last[0] != "ghost" {
+		t.Errorf("dedicated call asked %v, want [ghost]", last)
+	}
+}
+
+func TestBatchPoller_GroupsBySession(t *testing.T) {
+	s1 := newFakeBatchSession(map[string]string{"a": "ACTIVE", "c": "ACTIVE"})
+	s2 := newFakeBatchSession(map[string]string{"b": "ACTIVE", "d": "ACTIVE"})
+	p := newPoller(50*time.Millisecond, []tracker.BatchFetchFn{s1.Fn, s2.Fn})
+
+	// Rattachement séquentiel : a→s1, b→s2, c→s1, d→s2 (session la moins chargée)
+	for _, uuid := range []string{"a", "b", "c", "d"} {
+		if _, err := p.Fetch(context.Background(), uuid); err != nil {
+			t.Fatalf("Fetch(%s): %v", uuid, err)
+		}
+	}
+	s1.calls.Store(0)
+	s2.calls.Store(0)
+
+	results := fetchConcurrently(t, p, "a", "b", "c", "d")
+	if s1.calls.Load() != 1 || s2.calls.Load() != 1 {
+		t.Errorf("calls s1=%d s2=%d, want 1/1", s1.calls.Load(), s2.calls.Load())
+	}
+	for uuid, data := range results {
+		if id, _ := singleOrder(t, data); id != uuid {
+			t.Errorf("%s: wrong order demultiplexed", uuid)
+		}
+	}
+}
+
+func TestBatchPoller_ForgetDetachesOrder(t *testing.T) {
+	session := newFakeBatchSession(map[string]string{"a": "ACTIVE", "b": "ACTIVE"})
+	p := newPoller(10*time.Millisecond, []tracker.BatchFetchFn{session.Fn})
+
+	fetchConcurrently(t, p, "a", "b")
+	p.Forget("b")
+	fetchConcurrently(t, p, "a")
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if last := session.asked[len(session.asked)-1]; len(last) != 1 || last[0] != "a" {
+		t.Errorf("last call asked %v, want [a] after Forget(b)", last)
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Manager + BatchPoller
+// ══════════════════════════════════════════════════════════════
+
+func TestManager_WithBatchPolling_SharesCalls(t *testing.T) {
+	uuids := []string{"o1", "o2", "o3", "o4", "o5"}
+	phases := make(map[string]string, len(uuids))
+	for _, uuid := range uuids {
+		phases[uuid] = "COMPLETED"
+	}
+	session := newFakeBatchSession(phases)
+	poller := newPoller(100*time.Millisecond, []tracker.BatchFetchFn{session.Fn})
+
+	mgr := tracker.NewManager(testutil.NewMockOrderStore()).WithBatchPolling(poller)
+	for _, uuid := range uuids {
+		mgr.StartTracking(tracker.OrderIdentity{UUID: uuid})
+	}
+
+	seen := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for len(seen) < len(uuids) {
+		select {
+		case u := <-mgr.UpdateChannel:
+			if u.LastStatus != "COMPLETED" {
+				t.Errorf("%s: status = %s, want COMPLETED", u.UUID, u.LastStatus)
+			}
+			seen[u.UUID] = true
+		case <-timeout:
+			t.Fatalf("updates received = %d, want %d", len(seen), len(uuids))
+		}
+	}
+	mgr.Shutdown()
+
+	if got := session.calls.Load(); got >= int32(len(uuids)) {
+		t.Errorf("calls = %d, want fewer than %d tracked orders", got, len(uuids))
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Worker — réponse multi-commandes
+// ══════════════════════════════════════════════════════════════
+
+func TestWorker_MultiOrderResponseTracksOwnOrder(t *testing.T) {
+	const uuid = "3f2a9c1e-7b4d-4e8a-9f61-2c5d8e0a4b17" // seconde commande de la fixture
+	store := testutil.NewMockOrderStore()
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueResponse(testutil.LoadTestJSON(t, "order_multi.json"), nil)
+
+	mgr := tracker.NewManager(store, mockFetch.Fn()).WithMissTolerance(1)
+	defer mgr.Shutdown()
+	mgr.StartTracking(tracker.OrderIdentity{UUID: uuid, ChannelID: "ch-1", GuildID: "g1"})
+
+	select {
+	case u := <-mgr.UpdateChannel:
+		if u.LastStatus != "COMPLETED" {
+			t.Errorf("LastStatus = %q, want COMPLETED (the tracked order, not the first one)", u.LastStatus)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected an update")
+	}
+	if o, ok := store.GetOrder(uuid); !ok || o.LastStatus != "COMPLETED" {
+		t.Errorf("stored order = %+v (%v), want the COMPLETED order", o, ok)
+	}
+}
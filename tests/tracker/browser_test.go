@@ -0,0 +1,128 @@
+// Package tracker_test — Tests Black Box pour le package tracker (pool de navigateurs).
+package tracker_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tracker"
+	"github.com/superselle/ubertracker/tracker/trackertest"
+)
+
+// newDeadDebugger simule une URL de débogage distant qui ne répond pas au
+// protocole CDP : la connexion au navigateur échoue sans lancer Chrome.
+func newDeadDebugger(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestBrowserPool_RemoteFailureIsMeasured(t *testing.T) {
+	pool := tracker.NewBrowserPool(tracker.WithRemoteBrowser(newDeadDebugger(t)))
+	defer pool.Close()
+
+	if _, err := pool.FreshCookies(context.Background(), "http://example.invalid/orders/x", "", ""); err == nil {
+		t.Fatal("expected error from unreachable remote browser")
+	}
+
+	stats := pool.Stats()
+	if stats.Refreshes != 1 || stats.Failures != 1 {
+		t.Errorf("stats = %+v, want 1 refresh, 1 failure", stats)
+	}
+	if stats.LastLatency <= 0 || stats.MeanLatency() != stats.LastLatency {
+		t.Errorf("latency not recorded: %+v", stats)
+	}
+}
+
+func TestBrowserPool_ClosedPoolFails(t *testing.T) {
+	pool := tracker.NewBrowserPool()
+	pool.Close()
+
+	if _, err := pool.FreshCookies(context.Background(), "http://example.invalid/orders/x", "", ""); err == nil {
+		t.Fatal("expected error after Close")
+	}
+	if got := pool.Stats().Failures; got != 1 {
+		t.Errorf("Failures = %d, want 1", got)
+	}
+}
+
+func TestClient_WithBrowserPool_UsedForRefresh(t *testing.T) {
+	srv := trackertest.NewServer()
+	defer srv.Close()
+	srv.RequireCookie("sid", "ok")
+
+	pool := tracker.NewBrowserPool(tracker.WithRemoteBrowser(newDeadDebugger(t)))
+	defer pool.Close()
+	c := srv.Client(tracker.WithBrowserPool(pool))
+
+	_, err := c.Fetch(context.Background(), "order-1")
+	if !errors.Is(err, tracker.ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if got := pool.Stats().Refreshes; got != 1 {
+		t.Errorf("pool refreshes = %d, want 1 (refresh must go through the pool)", got)
+	}
+}
+
+func TestBrowserPool_CancelledContext(t *testing.T) {
+	pool := tracker.NewBrowserPool(tracker.WithRemoteBrowser(newDeadDebugger(t)))
+	defer pool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pool.FreshCookies(ctx, "http://example.invalid/orders/x", "", ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+// newStalledDebugger simule une URL de débogage distant qui ne répond jamais :
+// le démarrage du navigateur reste bloqué jusqu'à l'annulation de l'appelant.
+func newStalledDebugger(t *testing.T) string {
+	t.Helper()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	return srv.URL
+}
+
+func TestBrowserPool_LaunchDoesNotHoldPoolLock(t *testing.T) {
+	pool := tracker.NewBrowserPool(tracker.WithRemoteBrowser(newStalledDebugger(t)))
+	defer pool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := pool.FreshCookies(ctx, "http://example.invalid/orders/x", "", "")
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond) // démarrage en cours
+
+	stats := make(chan tracker.BrowserStats, 1)
+	go func() { stats <- pool.Stats() }()
+	select {
+	case <-stats:
+	case <-time.After(time.Second):
+		t.Fatal("Stats blocked while a browser was starting")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("FreshCookies did not return after cancellation")
+	}
+}
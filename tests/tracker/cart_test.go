@@ -0,0 +1,128 @@
+// Package tracker_test — Tests Black Box pour le package tracker (panier et montants).
+package tracker_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in, currency string
+		cents        int64
+		want         string
+	}{
+		{"24,90 €", "", 2490, "EUR"},
+		{"24,90\u00a0€", "", 2490, "EUR"}, // espace insécable
+		{"$1,234.50", "", 123450, "USD"},
+		{"12.50 USD", "", 1250, "USD"},
+		{"1 234,5 €", "", 123450, "EUR"},
+		{"£3", "", 300, "GBP"},
+		{"-3,00 €", "", -300, "EUR"},
+		{"CHF 7.10", "", 710, "CHF"},
+		{"1.234", "EUR", 123400, "EUR"}, // séparateur de milliers, pas de décimales
+		{"15.90€", "", 1590, "EUR"},
+		{"9,99", "eur", 999, "EUR"},
+	}
+	for _, tt := range tests {
+		m, err := tracker.ParseMoney(tt.in, tt.currency)
+		if err != nil {
+			t.Errorf("ParseMoney(%q) error: %v", tt.in, err)
+			continue
+		}
+		if m.Cents != tt.cents || m.Currency != tt.want {
+			t.Errorf("ParseMoney(%q) = %+v, want %d %s", tt.in, m, tt.cents, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "gratuit", "12-50 €", "€€"} {
+		if _, err := tracker.ParseMoney(bad, ""); !errors.Is(err, tracker.ErrInvalidMoney) {
+			t.Errorf("ParseMoney(%q) error = %v, want ErrInvalidMoney", bad, err)
+		}
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	if got := (tracker.Money{Cents: 2490, Currency: "EUR"}).String(); got != "24.90 EUR" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (tracker.Money{Cents: -5}).String(); got != "-0.05" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+// receiptOrder construit une commande au reçu complet.
+func receiptOrder() tracker.Order {
+	return testutil.NewTestOrder().
+		WithRestaurant("Burger Palace").
+		WithItems(
+			tracker.Item{
+				Title: "Classic Burger", Quantity: 2, Price: "8,50 €",
+				Customizations:      []tracker.ItemModifier{{Title: "Bacon", Price: "1,20 €"}, {Title: "Sans oignons"}},
+				SpecialInstructions: "Bien cuit",
+			},
+			tracker.Item{Title: "Frites maison", Quantity: 1, Price: "3,50 €"},
+		).
+		WithSummary(tracker.OrderSummary{
+			Total:    "27,40 €",
+			Subtotal: "22,90 €",
+			Fees:     []tracker.Fee{{Title: "Frais de livraison", Amount: "2,49 €"}, {Title: "Frais de service", Amount: "1,01 €"}},
+			Tip:      "1,00 €",
+		}).
+		Build()
+}
+
+func TestExtractCart(t *testing.T) {
+	cart := tracker.ExtractCart(receiptOrder())
+
+	if cart.Restaurant != "Burger Palace" || cart.Currency != "EUR" {
+		t.Errorf("cart = %+v", cart)
+	}
+	if cart.Total.Cents != 2740 || cart.Subtotal.Cents != 2290 || cart.Tip.Cents != 100 {
+		t.Errorf("total/subtotal/tip = %v / %v / %v", cart.Total, cart.Subtotal, cart.Tip)
+	}
+	if len(cart.Fees) != 2 || cart.Fees[0].Amount.Cents != 249 {
+		t.Errorf("fees = %+v", cart.Fees)
+	}
+	if len(cart.Lines) != 2 {
+		t.Fatalf("lines = %+v", cart.Lines)
+	}
+	burger := cart.Lines[0]
+	if burger.UnitPrice.Cents != 850 || len(burger.Modifiers) != 2 || burger.Instructions != "Bien cuit" {
+		t.Errorf("burger = %+v", burger)
+	}
+	// (8,50 + 1,20 + 0) × 2
+	if got := burger.Total(); got.Cents != 1940 || got.Currency != "EUR" {
+		t.Errorf("burger total = %v, want 19.40 EUR", got)
+	}
+}
+
+func TestMergeOrderData_PreservesCartDetails(t *testing.T) {
+	prev := receiptOrder()
+
+	// Uber ne renvoie plus que le titre des articles et le total.
+	next := testutil.NewTestOrder().
+		WithRestaurant("Burger Palace").
+		WithItems(tracker.Item{Title: "Classic Burger", Quantity: 2}, tracker.Item{Title: "Frites maison", Quantity: 1}).
+		WithTotal("27,40 €").
+		Build()
+
+	merged, _ := tracker.MergeOrderData(prev, next, true)
+	cart := tracker.ExtractCart(merged)
+	if cart.Tip.Cents != 100 || cart.Subtotal.Cents != 2290 || len(cart.Fees) != 2 {
+		t.Errorf("summary not preserved: %+v", cart)
+	}
+	if len(cart.Lines) != 2 || cart.Lines[0].UnitPrice.Cents != 850 || len(cart.Lines[0].Modifiers) != 2 {
+		t.Errorf("item details not preserved: %+v", cart.Lines)
+	}
+
+	// Plus aucun article : ceux du poll précédent sont restaurés.
+	empty := testutil.NewTestOrder().WithRestaurant("Burger Palace").Build()
+	merged, _ = tracker.MergeOrderData(merged, empty, true)
+	if cart := tracker.ExtractCart(merged); len(cart.Lines) != 2 || cart.Total.Cents != 2740 {
+		t.Errorf("cart after items dropped = %+v", cart)
+	}
+}
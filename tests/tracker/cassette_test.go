@@ -0,0 +1,236 @@
+// Package tracker_test — Tests Black Box pour le package tracker (cassettes et fixtures).
+package tracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+const cassetteUUID = "3f2a9c1e-7b4d-4e8a-9f61-2c5d8e0a4b17"
+
+// ══════════════════════════════════════════════════════════════
+// Recorder / Replayer
+// ══════════════════════════════════════════════════════════════
+
+func TestRecorder_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueOrder(testutil.NewTestOrder().WithPhase("ACTIVE").Build())
+	mockFetch.QueueError(&tracker.HTTPError{StatusCode: 503})
+	mockFetch.QueueError(&tracker.RateLimitError{RetryAfter: time.Second})
+
+	rec, err := tracker.NewRecorder(path, mockFetch.Fn())
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	ctx := context.Background()
+	first, _ := rec.Fetch(ctx, "uuid-1")
+	_, _ = rec.Fetch(ctx, "uuid-1")
+	_, _ = rec.Fetch(ctx, "uuid-1")
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	interactions, err := tracker.LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette: %v", err)
+	}
+	if len(interactions) != 3 {
+		t.Fatalf("interactions = %d, want 3", len(interactions))
+	}
+	if got := []int{interactions[0].Status, interactions[1].Status, interactions[2].Status}; got[0] != 200 || got[1] != 503 || got[2] != 429 {
+		t.Errorf("statuses = %v, want [200 503 429]", got)
+	}
+
+	replay := tracker.NewReplayer(interactions)
+	data, err := replay.Fetch(ctx, "uuid-1")
+	if err != nil || string(data) != string(first) {
+		t.Errorf("replayed body = %s (%v), want recorded body", data, err)
+	}
+	if _, err := replay.Fetch(ctx, "uuid-1"); !errors.Is(err, tracker.ErrServer) {
+		t.Errorf("replayed err = %v, want ErrServer", err)
+	}
+	if _, err := replay.Fetch(ctx, "uuid-1"); !errors.Is(err, tracker.ErrRateLimited) {
+		t.Errorf("replayed err = %v, want ErrRateLimited", err)
+	}
+	if _, err := replay.Fetch(ctx, "uuid-1"); !errors.Is(err, tracker.ErrCassetteExhausted) {
+		t.Errorf("err = %v, want ErrCassetteExhausted", err)
+	}
+}
+
+func TestRecorder_AppendsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	for i := 0; i < 2; i++ {
+		mockFetch := testutil.NewMockFetch()
+		mockFetch.QueueOrder(testutil.NewTestOrder().Build())
+		rec, err := tracker.NewRecorder(path, mockFetch.Fn())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = rec.Fetch(context.Background(), "uuid-1")
+		_ = rec.Close()
+	}
+	interactions, err := tracker.LoadCassette(path)
+	if err != nil || len(interactions) != 2 {
+		t.Errorf("LoadCassette = (%d, %v), want 2 interactions", len(interactions), err)
+	}
+}
+
+func TestLoadCassette_Malformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.jsonl")
+	if err := os.WriteFile(path, []byte("{not json}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tracker.LoadCassette(path); err == nil || !strings.Contains(err.Error(), "ligne 1") {
+		t.Errorf("err = %v, want line number", err)
+	}
+}
+
+func TestReplayer_Timing(t *testing.T) {
+	t0 := time.Date(2025, 3, 14, 19, 40, 0, 0, time.UTC)
+	interactions := []tracker.Interaction{
+		{UUID: "a", Time: t0, Status: 200, Body: json.RawMessage(`{}`)},
+		{UUID: "a", Time: t0.Add(10 * time.Second), Status: 200, Body: json.RawMessage(`{}`)},
+	}
+	ctx := context.Background()
+
+	// Accéléré 100× : 10 s → 100 ms
+	replay := tracker.NewReplayer(interactions, tracker.WithReplaySpeed(100))
+	start := time.Now()
+	_, _ = replay.Fetch(ctx, "a")
+	_, _ = replay.Fetch(ctx, "a")
+	if d := time.Since(start); d < 80*time.Millisecond || d > time.Second {
+		t.Errorf("accelerated replay took %v, want ~100ms", d)
+	}
+
+	// Sans option : pas d'attente
+	replay = tracker.NewReplayer(interactions)
+	start = time.Now()
+	_, _ = replay.Fetch(ctx, "a")
+	_, _ = replay.Fetch(ctx, "a")
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Errorf("instant replay took %v", d)
+	}
+	if replay.Remaining() != 0 {
+		t.Errorf("Remaining = %d, want 0", replay.Remaining())
+	}
+}
+
+func TestReplayer_TimingHonorsContext(t *testing.T) {
+	t0 := time.Now()
+	replay := tracker.NewReplayer([]tracker.Interaction{
+		{UUID: "a", Time: t0, Status: 200, Body: json.RawMessage(`{}`)},
+		{UUID: "a", Time: t0.Add(time.Hour), Status: 200, Body: json.RawMessage(`{}`)},
+	}, tracker.WithReplaySpeed(1))
+
+	_, _ = replay.Fetch(context.Background(), "a")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := replay.Fetch(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Régression : livraison enregistrée rejouée dans Reconcile
+// ══════════════════════════════════════════════════════════════
+
+func TestCassette_DeliveryReplayThroughReconcile(t *testing.T) {
+	ctx := context.Background()
+	store := testutil.NewMockOrderStore()
+	replay := tracker.NewReplayer(testutil.LoadTestCassette(t, "cassette_delivery.jsonl"))
+	id := tracker.OrderIdentity{UUID: cassetteUUID}
+
+	var phases []tracker.OrderPhase
+	var last tracker.ReconcileResult
+	for replay.Remaining() > 0 {
+		data, err := replay.Fetch(ctx, cassetteUUID)
+		if err != nil {
+			if !errors.Is(err, tracker.ErrServer) {
+				t.Fatalf("unexpected replay error: %v", err)
+			}
+			continue
+		}
+		var resp tracker.Response
+		if err := json.Unmarshal(data, &resp); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		last, err = tracker.Reconcile(ctx, store, id.UUID, resp)
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		if err := store.SaveOrder(ctx, tracker.TrackedOrder{
+			UUID: id.UUID, LastStatus: string(last.Phase), LastProgress: last.Progress,
+			LastText: last.Text, FullJSONData: last.FinalJSON,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		phases = append(phases, last.Phase)
+	}
+
+	if len(phases) != 7 || phases[len(phases)-1] != "COMPLETED" {
+		t.Fatalf("phases = %v, want 7 polls ending COMPLETED", phases)
+	}
+	for _, want := range []string{"24,90 €", "12 Rue de Paradis", "4821"} {
+		if !strings.Contains(last.FinalJSON, want) {
+			t.Errorf("final JSON lost preserved data %q", want)
+		}
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Fixtures testdata/
+// ══════════════════════════════════════════════════════════════
+
+func TestFixtures_PhaseAndETA(t *testing.T) {
+	tests := []struct {
+		file  string
+		phase tracker.OrderPhase
+		eta   int
+	}{
+		{"order_active.json", "ACTIVE", 12},
+		{"order_no_eta.json", "ACTIVE", -1},
+		{"order_with_pin.json", "ACTIVE", 4},
+		{"order_full.json", "ACTIVE", 7},
+		{"order_minimal.json", "ACTIVE", -1},
+		{"order_completed.json", "COMPLETED", -1},
+		{"order_cancelled.json", "CANCELLED", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			resp := testutil.LoadTestResponse(t, tt.file)
+			if len(resp.Data.Orders) != 1 {
+				t.Fatalf("orders = %d, want 1", len(resp.Data.Orders))
+			}
+			o := resp.Data.Orders[0]
+			if _, phase := tracker.MergeOrderData(o, o, false); phase != tt.phase {
+				t.Errorf("phase = %q, want %q", phase, tt.phase)
+			}
+			if eta := tracker.ExtractETAFromOrder(o); eta != tt.eta {
+				t.Errorf("ETA = %d, want %d", eta, tt.eta)
+			}
+		})
+	}
+}
+
+func TestFixtures_PinPreservedWhenDropped(t *testing.T) {
+	withPin := testutil.LoadTestResponse(t, "order_with_pin.json").Data.Orders[0]
+	completed := testutil.LoadTestResponse(t, "order_completed.json").Data.Orders[0]
+
+	merged, _ := tracker.MergeOrderData(withPin, completed, true)
+	raw, _ := json.Marshal(merged)
+	for _, want := range []string{"4821", "24,90 €", "12 Rue de Paradis"} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("merged order lost %q", want)
+		}
+	}
+}
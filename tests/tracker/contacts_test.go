@@ -0,0 +1,157 @@
+// Package tracker_test — Tests Black Box pour le package tracker (contacts).
+package tracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+func TestContact_Role(t *testing.T) {
+	tests := []struct {
+		contact tracker.Contact
+		want    tracker.ContactRole
+	}{
+		{tracker.Contact{Title: "Karim"}, tracker.RoleUnknown},
+		{tracker.Contact{Title: "burger palace "}, tracker.RoleRestaurant},
+		{tracker.Contact{Title: "Burger Palace", Type: "courier"}, tracker.RoleCourier},
+		{tracker.Contact{Title: "Support", Type: "RESTAURANT"}, tracker.RoleRestaurant},
+		{tracker.Contact{}, tracker.RoleUnknown},
+	}
+	for _, tt := range tests {
+		if got := tt.contact.Role("Burger Palace"); got != tt.want {
+			t.Errorf("Role(%+v) = %q, want %q", tt.contact, got, tt.want)
+		}
+	}
+}
+
+func TestRedactPhone(t *testing.T) {
+	const phone = "+33 6 12 34 56 78"
+	tests := []struct {
+		mode tracker.PhoneRedaction
+		want string
+	}{
+		{tracker.RedactNone, phone},
+		{tracker.RedactPartial, "+•• • •• •• •• 78"},
+		{tracker.RedactFull, ""},
+	}
+	for _, tt := range tests {
+		if got := tracker.RedactPhone(phone, tt.mode); got != tt.want {
+			t.Errorf("RedactPhone(%d) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestExtractContacts_Fixture(t *testing.T) {
+	resp := testutil.LoadTestResponse(t, "order_full.json")
+	oc := tracker.ExtractContacts(resp.Data.Orders[0])
+
+	if oc.Courier == nil || oc.Courier.Name != "Karim" || oc.Courier.Phone == "" {
+		t.Errorf("Courier = %+v, want Karim with a phone", oc.Courier)
+	}
+	if oc.Restaurant == nil || oc.Restaurant.Name != "Burger Palace" {
+		t.Errorf("Restaurant = %+v, want Burger Palace", oc.Restaurant)
+	}
+}
+
+func TestExtractContacts_CourierProfile(t *testing.T) {
+	order := testutil.NewTestOrder().
+		WithRestaurant("Burger Palace").
+		WithContact("Karim", "+33 6 12 34 56 78").
+		WithPIN("4821").
+		WithCourierProfile(tracker.CourierInfo{Name: "Karim B.", VehicleType: "BICYCLE", Rating: 4.9, PictureURL: "https://example.test/k.jpg"}).
+		Build()
+
+	oc := tracker.ExtractContacts(order)
+	want := tracker.CourierProfile{Name: "Karim B.", Phone: "+33 6 12 34 56 78", VehicleType: "BICYCLE", Rating: 4.9, PhotoURL: "https://example.test/k.jpg"}
+	if oc.Courier == nil || *oc.Courier != want {
+		t.Errorf("Courier = %+v, want %+v", oc.Courier, want)
+	}
+	if oc.Restaurant != nil {
+		t.Errorf("Restaurant = %+v, want nil", oc.Restaurant)
+	}
+
+	redacted := oc.Redacted(tracker.RedactFull)
+	if redacted.Courier.Phone != "" || oc.Courier.Phone == "" {
+		t.Error("Redacted must mask a copy, not the original")
+	}
+}
+
+func TestMergeOrderData_PreservesContactsByRole(t *testing.T) {
+	prev := testutil.NewTestOrder().
+		WithRestaurant("Burger Palace").
+		WithContact("Karim", "+33 6 12 34 56 78").
+		WithContact("Burger Palace", "+33 1 42 00 00 00").
+		WithPIN("4821").
+		WithCourierProfile(tracker.CourierInfo{Name: "Karim", VehicleType: "SCOOTER", Rating: 4.8}).
+		Build()
+
+	// Le livreur disparaît des contacts et des FeedCards ; le restaurant reste.
+	next := testutil.NewTestOrder().
+		WithRestaurant("Burger Palace").
+		WithContact("Burger Palace", "+33 1 42 00 00 00").
+		Build()
+
+	merged, _ := tracker.MergeOrderData(prev, next, true)
+	oc := tracker.ExtractContacts(merged)
+	if oc.Courier == nil || oc.Courier.Phone != "+33 6 12 34 56 78" || oc.Courier.VehicleType != "SCOOTER" || oc.Courier.Rating != 4.8 {
+		t.Errorf("Courier = %+v, want the preserved profile", oc.Courier)
+	}
+	if oc.Restaurant == nil || oc.Restaurant.Phone != "+33 1 42 00 00 00" {
+		t.Errorf("Restaurant = %+v", oc.Restaurant)
+	}
+	if len(merged.Contacts) != 2 {
+		t.Errorf("Contacts = %+v, want 2 (no duplicate)", merged.Contacts)
+	}
+	if pin := merged.FeedCards[0].Courier[0].PinInfo.Pin; pin != "4821" {
+		t.Errorf("PIN = %q, want 4821 alongside the profile", pin)
+	}
+}
+
+func TestExtractContacts_UnclassifiedWithoutCourier(t *testing.T) {
+	// Aucun livreur affecté : un contact qui n'est pas le restaurant n'est
+	// pas pris pour le livreur.
+	order := testutil.NewTestOrder().
+		WithRestaurant("Burger Palace").
+		WithContact("Service client", "+33 1 00 00 00 00").
+		Build()
+
+	if oc := tracker.ExtractContacts(order); oc.Courier != nil {
+		t.Errorf("Courier = %+v, want nil without an assigned courier", oc.Courier)
+	}
+}
+
+func TestManager_PhoneRedaction(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueResponse(testutil.LoadTestJSON(t, "order_full.json"), nil)
+
+	mgr := tracker.NewManager(store, mockFetch.Fn()).
+		WithPhoneRedaction(tracker.RedactPartial)
+	defer mgr.Shutdown()
+	mgr.StartTracking(tracker.OrderIdentity{UUID: "uuid-full", ChannelID: "ch-1", GuildID: "g1"})
+
+	select {
+	case u := <-mgr.UpdateChannel:
+		if u.Contacts.Courier == nil || u.Contacts.Courier.Phone != "+•• • •• •• •• 78" {
+			t.Errorf("Courier = %+v, want a partially masked phone", u.Contacts.Courier)
+		}
+		if strings.Contains(u.FullJSONData, "12 34 56") {
+			t.Error("FullJSONData exposes the raw courier phone")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected an update")
+	}
+
+	_, _, _, snapshot, _ := store.GetSnapshot(context.Background(), "uuid-full")
+	history, _ := store.GetHistory(context.Background(), "uuid-full")
+	rawHistory, _ := json.Marshal(history)
+	if strings.Contains(snapshot, "12 34 56") || len(history) == 0 || strings.Contains(string(rawHistory), "12 34 56") {
+		t.Error("stored snapshot or history exposes the raw courier phone")
+	}
+}
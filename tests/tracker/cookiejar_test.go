@@ -0,0 +1,147 @@
+// Package tracker_test — Tests Black Box pour le package tracker (cookie jar).
+package tracker_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+// ══════════════════════════════════════════════════════════════
+// CookieJar
+// ══════════════════════════════════════════════════════════════
+
+func TestCookieJar_MergeSetCookie_KeepsMetadata(t *testing.T) {
+	jar := tracker.ParseCookieHeader("sid=old")
+	n := jar.MergeSetCookie([]string{
+		"sid=new; Domain=.ubereats.com; Path=/; Max-Age=3600; HttpOnly; Secure",
+		"dId=42; Path=/",
+	})
+	if n != 2 {
+		t.Fatalf("merged = %d, want 2", n)
+	}
+
+	cookies := jar.Cookies()
+	if len(cookies) != 2 {
+		t.Fatalf("len = %d, want 2 (sid replaced, dId added)", len(cookies))
+	}
+	sid := cookies[0]
+	if sid.Value != "new" || sid.Domain != ".ubereats.com" || !sid.HTTPOnly || !sid.Secure {
+		t.Errorf("sid = %+v, metadata lost", sid)
+	}
+	if sid.Expires.IsZero() || time.Until(sid.Expires) < 59*time.Minute {
+		t.Errorf("sid.Expires = %v, want ~1h from now", sid.Expires)
+	}
+}
+
+func TestCookieJar_MergeSetCookie_DeletesExpired(t *testing.T) {
+	jar := tracker.ParseCookieHeader("sid=abc; dId=42")
+	jar.MergeSetCookie([]string{"sid=; Max-Age=-1"})
+
+	if got := jar.Header(); got != "dId=42" {
+		t.Errorf("Header = %q, want dId=42", got)
+	}
+}
+
+func TestCookieJar_EarliestExpiry(t *testing.T) {
+	now := time.Now()
+	jar := tracker.NewCookieJar(
+		tracker.Cookie{Name: "sid", Value: "1", Expires: now.Add(2 * time.Hour)},
+		tracker.Cookie{Name: "tracking", Value: "2", Expires: now.Add(10 * time.Minute)},
+		tracker.Cookie{Name: "session", Value: "3"},
+	)
+
+	all, ok := jar.EarliestExpiry()
+	if !ok || !all.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("EarliestExpiry() = %v, want tracking expiry", all)
+	}
+	critical, ok := jar.EarliestExpiry("sid", "session")
+	if !ok || !critical.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("EarliestExpiry(sid, session) = %v, want sid expiry", critical)
+	}
+	if _, ok := jar.EarliestExpiry("session"); ok {
+		t.Error("session cookie has no expiry, ok should be false")
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Client + CookieJar
+// ══════════════════════════════════════════════════════════════
+
+func TestClient_MergesSetCookieIntoJar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add("Set-Cookie", "jwt-session=rotated; Path=/; Max-Age=86400")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	jar := tracker.ParseCookieHeader("jwt-session=initial; dId=1")
+	client := tracker.NewClient(
+		tracker.WithBaseURL(srv.URL),
+		tracker.WithCookieProvider(tracker.NewMemoryCookieProvider(jar)),
+	)
+
+	if _, err := client.Fetch(context.Background(), "uuid-1"); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if got := jar.Header(); got != "jwt-session=rotated; dId=1" {
+		t.Errorf("jar = %q, want rotated jwt-session", got)
+	}
+}
+
+func TestClient_ProactiveRefreshBeforeExpiry(t *testing.T) {
+	var gotCookie string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCookie = r.Header.Get("Cookie")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	expiring := tracker.NewCookieJar(tracker.Cookie{
+		Name: "jwt-session", Value: "old", Expires: time.Now().Add(30 * time.Second),
+	})
+	refresher := testutil.NewMockCookieProvider("", "jwt-session=new")
+	client := tracker.NewClient(
+		tracker.WithBaseURL(srv.URL),
+		tracker.WithCookieProvider(tracker.NewMemoryCookieProvider(expiring, refresher)),
+		tracker.WithRefreshMargin(time.Minute),
+	)
+
+	if _, err := client.Fetch(context.Background(), "uuid-1"); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if refresher.RefreshCount() != 1 {
+		t.Errorf("Refresh calls = %d, want 1 (proactive)", refresher.RefreshCount())
+	}
+	if gotCookie != "jwt-session=new" {
+		t.Errorf("Cookie sent = %q, want jwt-session=new", gotCookie)
+	}
+}
+
+func TestClient_NoProactiveRefreshWhenFarFromExpiry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	jar := tracker.NewCookieJar(tracker.Cookie{
+		Name: "jwt-session", Value: "ok", Expires: time.Now().Add(24 * time.Hour),
+	})
+	refresher := testutil.NewMockCookieProvider("")
+	client := tracker.NewClient(
+		tracker.WithBaseURL(srv.URL),
+		tracker.WithCookieProvider(tracker.NewMemoryCookieProvider(jar, refresher)),
+	)
+
+	if _, err := client.Fetch(context.Background(), "uuid-1"); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if refresher.RefreshCount() != 0 {
+		t.Errorf("Refresh calls = %d, want 0", refresher.RefreshCount())
+	}
+}
@@ -0,0 +1,374 @@
+// Package tracker_test — Tests Black Box pour le package tracker (cookies).
+package tracker_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+	"github.com/superselle/ubertracker/tracker/trackertest"
+)
+
+// ══════════════════════════════════════════════════════════════
+// MemoryCookieProvider
+// ══════════════════════════════════════════════════════════════
+
+func TestMemoryCookieProvider_GetAndRefresh(t *testing.T) {
+	ctx := context.Background()
+	refresher := testutil.NewMockCookieProvider("", "sid=new")
+	p := tracker.NewMemoryCookieProvider(tracker.ParseCookieHeader("sid=old"), refresher)
+
+	if got, _ := p.Get(ctx); got.Header() != "sid=old" {
+		t.Errorf("Get = %q, want sid=old", got.Header())
+	}
+	if _, err := p.Refresh(ctx, "https://example.test/orders/x"); err != nil {
+		t.Fatalf("Refresh error: %v", err)
+	}
+	if got, _ := p.Get(ctx); got.Header() != "sid=new" {
+		t.Errorf("Get after refresh = %q, want sid=new", got.Header())
+	}
+}
+
+func TestMemoryCookieProvider_RefreshWithoutRefresher(t *testing.T) {
+	p := tracker.NewMemoryCookieProvider(tracker.ParseCookieHeader("sid=old"))
+	if _, err := p.Refresh(context.Background(), ""); err == nil {
+		t.Error("Refresh without refresher should fail")
+	}
+}
+
+func TestMemoryCookieProvider_InvalidateKeepsJarUntilRefresh(t *testing.T) {
+	ctx := context.Background()
+	refresher := testutil.NewMockCookieProvider("")
+	refresher.RefreshErr = errors.New("navigateur indisponible")
+	p := tracker.NewMemoryCookieProvider(tracker.ParseCookieHeader("sid=old"), refresher)
+
+	p.Invalidate()
+	if _, err := p.Refresh(ctx, ""); err == nil {
+		t.Fatal("Refresh should fail")
+	}
+	if got, _ := p.Get(ctx); got.Header() != "sid=old" {
+		t.Errorf("Get after failed refresh = %q, want sid=old", got.Header())
+	}
+	if refresher.InvalidateCount() != 1 {
+		t.Errorf("refresher Invalidate calls = %d, want 1", refresher.InvalidateCount())
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// FileCookieProvider
+// ══════════════════════════════════════════════════════════════
+
+func TestFileCookieProvider_ReadsNetscape(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	content := "# Netscape HTTP Cookie File\n" +
+		".ubereats.com\tTRUE\t/\tTRUE\t0\tsid\tabc\n" +
+		"#HttpOnly_.ubereats.com\tTRUE\t/\tTRUE\t4102444800\tjwt-session\txyz\n" +
+		".ubereats.com\tTRUE\t/\tFALSE\t1\texpired\tgone\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tracker.NewFileCookieProvider(path).Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if got.Header() != "sid=abc; jwt-session=xyz" {
+		t.Errorf("Get = %q, want 'sid=abc; jwt-session=xyz'", got.Header())
+	}
+}
+
+func TestFileCookieProvider_ReadsJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	content := `[{"name":"sid","value":"abc","domain":".ubereats.com"},{"name":"dId","value":"42"}]`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tracker.NewFileCookieProvider(path).Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if got.Header() != "sid=abc; dId=42" {
+		t.Errorf("Get = %q, want 'sid=abc; dId=42'", got.Header())
+	}
+}
+
+func TestFileCookieProvider_MissingFileIsEmpty(t *testing.T) {
+	p := tracker.NewFileCookieProvider(filepath.Join(t.TempDir(), "absent.txt"))
+	got, err := p.Get(context.Background())
+	if err != nil || got.Len() != 0 {
+		t.Errorf("Get = (%d cookies, %v), want empty and no error", got.Len(), err)
+	}
+}
+
+func TestFileCookieProvider_RefreshPersistsAcrossRestarts(t *testing.T) {
+	for _, name := range []string{"cookies.txt", "cookies.json"} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), name)
+
+			p := tracker.NewFileCookieProvider(path, testutil.NewMockCookieProvider("", "sid=fresh; dId=7"))
+			if _, err := p.Refresh(ctx, ""); err != nil {
+				t.Fatalf("Refresh error: %v", err)
+			}
+
+			// Nouveau provider = redémarrage du processus
+			got, err := tracker.NewFileCookieProvider(path).Get(ctx)
+			if err != nil {
+				t.Fatalf("Get after restart: %v", err)
+			}
+			if got.Header() != "sid=fresh; dId=7" {
+				t.Errorf("Get after restart = %q, want 'sid=fresh; dId=7'", got.Header())
+			}
+		})
+	}
+}
+
+func TestFileCookieProvider_EmptyValueRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+
+	p := tracker.NewFileCookieProvider(path, testutil.NewMockCookieProvider("", "sid=fresh; consent="))
+	if _, err := p.Refresh(ctx, ""); err != nil {
+		t.Fatalf("Refresh error: %v", err)
+	}
+
+	got, err := tracker.NewFileCookieProvider(path).Get(ctx)
+	if err != nil {
+		t.Fatalf("Get after restart: %v", err)
+	}
+	if got.Header() != "sid=fresh; consent=" {
+		t.Errorf("Get after restart = %q, want 'sid=fresh; consent='", got.Header())
+	}
+}
+
+func TestFileCookieProvider_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte("not\ta\tcookie\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tracker.NewFileCookieProvider(path).Get(context.Background()); err == nil {
+		t.Error("Get should fail on malformed Netscape file")
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Client + CookieProvider
+// ══════════════════════════════════════════════════════════════
+
+func TestClient_RefreshesCookiesOnUnauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Cookie"), "sid=fresh") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"orders":[]}}`))
+	}))
+	defer srv.Close()
+
+	provider := testutil.NewMockCookieProvider("sid=stale", "sid=fresh")
+	client := tracker.NewClient(
+		tracker.WithBaseURL(srv.URL),
+		tracker.WithCookieProvider(provider),
+	)
+
+	if _, err := client.Fetch(context.Background(), "uuid-1"); err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if provider.RefreshCount() != 1 {
+		t.Errorf("Refresh calls = %d, want 1", provider.RefreshCount())
+	}
+	if provider.InvalidateCount() != 1 {
+		t.Errorf("Invalidate calls = %d, want 1", provider.InvalidateCount())
+	}
+}
+
+func TestBrowserCookieProvider_KeepsJarWhenRefreshFails(t *testing.T) {
+	srv := trackertest.NewServer()
+	defer srv.Close()
+	srv.AddOrder("order-1", trackertest.Phases("ACTIVE")...)
+	srv.SetCookieOnSuccess("sid=ok; Path=/")
+
+	pool := tracker.NewBrowserPool()
+	pool.Close() // tout renouvellement échoue
+	client := srv.Client(tracker.WithBrowserPool(pool))
+	ctx := context.Background()
+
+	if _, err := client.Fetch(ctx, "order-1"); err != nil {
+		t.Fatalf("first Fetch error: %v", err)
+	}
+	srv.Fail(trackertest.Fault{Status: http.StatusForbidden})
+	if _, err := client.Fetch(ctx, "order-1"); err == nil {
+		t.Fatal("Fetch should fail when the refresh fails")
+	}
+	if _, err := client.Fetch(ctx, "order-1"); err != nil {
+		t.Fatalf("Fetch after failed refresh: %v", err)
+	}
+
+	reqs := srv.Requests()
+	if last := reqs[len(reqs)-1]; !strings.Contains(last.Cookie, "sid=ok") {
+		t.Errorf("cookies after failed refresh = %q, want sid=ok kept", last.Cookie)
+	}
+}
+
+// blockingRefresher simule un navigateur lent : Refresh attend release (ou
+// l'annulation de son contexte) avant de retourner sid=ok.
+type blockingRefresher struct {
+	started  chan struct{}
+	release  chan struct{}
+	calls    atomic.Int32
+	canceled atomic.Bool
+}
+
+func newBlockingRefresher() *blockingRefresher {
+	return &blockingRefresher{started: make(chan struct{}, 8), release: make(chan struct{})}
+}
+
+func (b *blockingRefresher) Get(context.Context) (*tracker.CookieJar, error) {
+	return tracker.NewCookieJar(), nil
+}
+
+func (b *blockingRefresher) Refresh(ctx context.Context, _ string) (*tracker.CookieJar, error) {
+	b.calls.Add(1)
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+		return tracker.ParseCookieHeader("sid=ok"), nil
+	case <-ctx.Done():
+		b.canceled.Store(true)
+		return nil, ctx.Err()
+	}
+}
+
+func (b *blockingRefresher) Invalidate() {}
+
+func TestClient_RefreshAbortedWhenCallerCancels(t *testing.T) {
+	srv := trackertest.NewServer()
+	defer srv.Close()
+	srv.RequireCookie("sid", "ok")
+
+	refresher := newBlockingRefresher()
+	client := srv.Client(tracker.WithCookieProvider(refresher))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Fetch(ctx, "order-1")
+		done <- err
+	}()
+
+	<-refresher.started
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Fetch did not return after cancellation")
+	}
+	deadline := time.Now().Add(time.Second)
+	for !refresher.canceled.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !refresher.canceled.Load() {
+		t.Error("refresh should be aborted once no caller waits for it")
+	}
+}
+
+func TestClient_SharedRefreshSurvivesOneCancelledCaller(t *testing.T) {
+	srv := trackertest.NewServer()
+	defer srv.Close()
+	srv.RequireCookie("sid", "ok")
+	srv.AddOrder("order-1", trackertest.Phases("EN_ROUTE")...)
+
+	refresher := newBlockingRefresher()
+	client := srv.Client(tracker.WithCookieProvider(refresher))
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	err1 := make(chan error, 1)
+	go func() {
+		_, err := client.Fetch(ctx1, "order-1")
+		err1 <- err
+	}()
+	<-refresher.started
+
+	err2 := make(chan error, 1)
+	go func() {
+		_, err := client.Fetch(context.Background(), "order-1")
+		err2 <- err
+	}()
+	// Laisse le second appelant recevoir son 403 et rejoindre le renouvellement.
+	for srv.Hits("order-1") < 2 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	cancel1()
+	if err := <-err1; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller err = %v, want context.Canceled", err)
+	}
+	if refresher.canceled.Load() {
+		t.Fatal("refresh aborted while another caller still waits for it")
+	}
+
+	close(refresher.release)
+	if err := <-err2; err != nil {
+		t.Errorf("second caller err = %v, want nil", err)
+	}
+	if got := refresher.calls.Load(); got != 1 {
+		t.Errorf("Refresh calls = %d, want 1 (deduplicated)", got)
+	}
+}
+
+func TestClient_RefreshAfterAbandonStartsAnew(t *testing.T) {
+	srv := trackertest.NewServer()
+	defer srv.Close()
+	srv.RequireCookie("sid", "ok")
+	srv.AddOrder("order-1", trackertest.Phases("ACTIVE")...)
+
+	refresher := newBlockingRefresher()
+	client := srv.Client(tracker.WithCookieProvider(refresher))
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	err1 := make(chan error, 1)
+	go func() {
+		_, err := client.Fetch(ctx1, "order-1")
+		err1 <- err
+	}()
+	<-refresher.started
+	cancel1()
+	if err := <-err1; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller err = %v, want context.Canceled", err)
+	}
+
+	// Le renouvellement abandonné ne doit pas être rejoint, même s'il n'a
+	// pas encore fini de s'arrêter.
+	err2 := make(chan error, 1)
+	go func() {
+		_, err := client.Fetch(context.Background(), "order-1")
+		err2 <- err
+	}()
+	select {
+	case <-refresher.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("second caller did not start a new refresh")
+	}
+	close(refresher.release)
+	if err := <-err2; err != nil {
+		t.Errorf("second caller err = %v, want nil", err)
+	}
+	if got := refresher.calls.Load(); got != 2 {
+		t.Errorf("Refresh calls = %d, want 2", got)
+	}
+}
@@ -0,0 +1,213 @@
+// Package tracker_test — Tests Black Box pour le package tracker (erreurs typées).
+package tracker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+// newStatusServer répond toujours status, avec les headers fournis.
+func newStatusServer(t *testing.T, status int, headers map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// ══════════════════════════════════════════════════════════════
+// Client.Fetch — classification
+// ══════════════════════════════════════════════════════════════
+
+func TestClient_Fetch_ErrorClasses(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   error
+	}{
+		{"unauthorized", http.StatusUnauthorized, tracker.ErrUnauthorized},
+		{"forbidden", http.StatusForbidden, tracker.ErrUnauthorized},
+		{"rate limited", http.StatusTooManyRequests, tracker.ErrRateLimited},
+		{"server", http.StatusBadGateway, tracker.ErrServer},
+		{"not found", http.StatusNotFound, tracker.ErrOrderNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newStatusServer(t, tt.status, nil)
+			client := tracker.NewClient(
+				tracker.WithBaseURL(srv.URL),
+				tracker.WithCookieProvider(testutil.NewMockCookieProvider("sid=1", "sid=2")),
+			)
+
+			_, err := client.Fetch(context.Background(), "uuid-1")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want errors.Is(%v)", err, tt.want)
+			}
+			var httpErr *tracker.HTTPError
+			if tt.status != http.StatusTooManyRequests && (!errors.As(err, &httpErr) || httpErr.StatusCode != tt.status) {
+				t.Errorf("errors.As(*HTTPError) status = %v, want %d", httpErr, tt.status)
+			}
+		})
+	}
+}
+
+func TestClient_Fetch_RateLimitedRetryAfter(t *testing.T) {
+	srv := newStatusServer(t, http.StatusTooManyRequests, map[string]string{"Retry-After": "42"})
+	client := tracker.NewClient(tracker.WithBaseURL(srv.URL), tracker.WithCookies("sid=1"))
+
+	_, err := client.Fetch(context.Background(), "uuid-1")
+	d, ok := tracker.RetryAfter(err)
+	if !ok || d != 42*time.Second {
+		t.Errorf("RetryAfter = (%v, %v), want (42s, true)", d, ok)
+	}
+}
+
+func TestClient_Fetch_NetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close() // plus personne n'écoute
+
+	client := tracker.NewClient(tracker.WithBaseURL(url), tracker.WithTimeout(2*time.Second))
+	if _, err := client.Fetch(context.Background(), "uuid-1"); !errors.Is(err, tracker.ErrNetwork) {
+		t.Errorf("err = %v, want ErrNetwork", err)
+	}
+}
+
+func TestSessionPool_ServerErrorDoesNotQuarantine(t *testing.T) {
+	srv := newStatusServer(t, http.StatusInternalServerError, nil)
+	pool := tracker.NewSessionPool([]*tracker.Client{
+		tracker.NewClient(tracker.WithBaseURL(srv.URL), tracker.WithCookies("sid=1")),
+	}, tracker.WithQuarantine(1, time.Hour))
+
+	for i := 0; i < 3; i++ {
+		if _, err := pool.Fetch(context.Background(), "uuid-1"); !errors.Is(err, tracker.ErrServer) {
+			t.Fatalf("Fetch #%d err = %v, want ErrServer", i, err)
+		}
+	}
+	if st := pool.Stats()[0]; st.Quarantined(time.Now()) || st.Requests != 3 {
+		t.Errorf("stats = %+v, want 3 requests and no quarantine", st)
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Worker — réaction par classe d'erreur
+// ══════════════════════════════════════════════════════════════
+
+func TestWorker_EmptyOrderListIsNotTerminal(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueResponse([]byte(`{"data":{"orders":[]}}`), nil)
+	updates := make(chan tracker.TrackedOrder, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	id := tracker.OrderIdentity{UUID: "uuid-late", ChannelID: "ch-1", GuildID: "g1"}
+
+	done := make(chan struct{})
+	go func() {
+		tracker.StartOrderWorker(ctx, store, id, updates, mockFetch.Fn())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("worker stopped on a single empty order list")
+	case u := <-updates:
+		t.Fatalf("unexpected update %+v", u)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("worker did not stop on cancel")
+	}
+	if mockFetch.CallCount() != 1 {
+		t.Errorf("fetch calls = %d, want 1", mockFetch.CallCount())
+	}
+}
+
+func TestManager_AbandonsAfterConsecutiveMisses(t *testing.T) {
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueError(fmt.Errorf("upstream: %w", tracker.ErrOrderNotFound))
+
+	mgr := tracker.NewManager(testutil.NewMockOrderStore(), mockFetch.Fn()).WithMissTolerance(1)
+	defer mgr.Shutdown()
+	mgr.StartTracking(tracker.OrderIdentity{UUID: "uuid-404", ChannelID: "ch-1", GuildID: "g1"})
+
+	select {
+	case u := <-mgr.UpdateChannel:
+		if u.LastStatus != "FAILED" || u.LastText != tracker.LocaleFrench.OrderNotFound {
+			t.Errorf("update = %q/%q, want FAILED/%q", u.LastStatus, u.LastText, tracker.LocaleFrench.OrderNotFound)
+		}
+		if len(u.Events) != 1 || u.Events[0].Kind != tracker.EventTrackingFailed {
+			t.Errorf("Events = %+v, want one TRACKING_FAILED", u.Events)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected a FAILED update once the miss budget is spent")
+	}
+}
+
+func TestWorker_RateLimitedKeepsRunning(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueError(&tracker.RateLimitError{RetryAfter: time.Minute})
+	updates := make(chan tracker.TrackedOrder, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		tracker.StartOrderWorker(ctx, store, tracker.OrderIdentity{UUID: "uuid-429"}, updates, mockFetch.Fn())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("worker should keep running (backing off) after a rate limit")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("worker did not exit after context cancel")
+	}
+}
+
+func TestFetchAndParse_MalformedJSONIsSchemaError(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueResponse([]byte(`{not json`), nil)
+	updates := make(chan tracker.TrackedOrder, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		tracker.StartOrderWorker(ctx, store, tracker.OrderIdentity{UUID: "uuid-bad"}, updates, mockFetch.Fn())
+		close(done)
+	}()
+
+	// Un JSON invalide est un échec ordinaire : le worker continue.
+	select {
+	case <-done:
+		t.Fatal("worker should not stop on a single malformed response")
+	case <-time.After(200 * time.Millisecond):
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,127 @@
+// Package tracker_test — Tests Black Box pour le package tracker (estimation de l'ETA).
+package tracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+var etaT0 = time.Date(2024, 5, 1, 19, 30, 0, 0, time.UTC)
+
+func TestETAEstimator_UberOnly(t *testing.T) {
+	e := tracker.NewETAEstimator()
+	got := e.Estimate(tracker.ETAInput{At: etaT0, UberETA: 12, Phase: tracker.PhaseActive})
+	if got.Minutes != 12 || got.Jumping || got.Confidence < 0.8 {
+		t.Errorf("estimate = %+v, want 12 min with high confidence", got)
+	}
+
+	// Une minute plus tard, Uber annonce 11 min : cohérent, pas d'instabilité.
+	got = e.Estimate(tracker.ETAInput{At: etaT0.Add(time.Minute), UberETA: 11, Phase: tracker.PhaseActive})
+	if got.Minutes != 11 || got.Jumping {
+		t.Errorf("estimate = %+v, want 11 min, stable", got)
+	}
+}
+
+func TestETAEstimator_JumpingUberETA(t *testing.T) {
+	e := tracker.NewETAEstimator()
+	e.Estimate(tracker.ETAInput{At: etaT0, UberETA: 10, Phase: tracker.PhaseActive})
+
+	got := e.Estimate(tracker.ETAInput{At: etaT0.Add(time.Minute), UberETA: 35, Phase: tracker.PhaseActive})
+	if !got.Jumping {
+		t.Fatalf("estimate = %+v, want Jumping", got)
+	}
+	// Le saut est amorti par l'estimation précédente (≈ 9 min).
+	if got.Minutes <= 9 || got.Minutes >= 35 {
+		t.Errorf("Minutes = %d, want between 9 and 35", got.Minutes)
+	}
+	if got.Confidence >= 0.9 {
+		t.Errorf("Confidence = %.2f, want reduced", got.Confidence)
+	}
+}
+
+func TestETAEstimator_DistanceFallback(t *testing.T) {
+	e := tracker.NewETAEstimator()
+	motion := tracker.CourierMotion{DistanceRemaining: 1200, SmoothedSpeed: 6.5}
+
+	// Pas d'ETA Uber : 1200 m × 1,3 / 6,5 m/s = 240 s = 4 min.
+	got := e.Estimate(tracker.ETAInput{At: etaT0, UberETA: -1, Phase: tracker.PhaseActive, EnRoute: true, Motion: motion})
+	if got.Minutes != 4 || got.Confidence <= 0 {
+		t.Errorf("estimate = %+v, want 4 min from distance", got)
+	}
+
+	// Livreur quasi immobile : vitesse par défaut, confiance moindre.
+	slow := tracker.NewETAEstimator()
+	motion.SmoothedSpeed = 0.2
+	fallback := slow.Estimate(tracker.ETAInput{At: etaT0, UberETA: -1, Phase: tracker.PhaseActive, EnRoute: true, Motion: motion})
+	if fallback.Minutes < 0 || fallback.Confidence >= got.Confidence {
+		t.Errorf("fallback = %+v, want an estimate less confident than %+v", fallback, got)
+	}
+}
+
+func TestETAEstimator_ProgressAndDecay(t *testing.T) {
+	e := tracker.NewETAEstimator()
+	in := tracker.ETAInput{UberETA: -1, Phase: tracker.PhaseActive, TotalProgress: 4}
+
+	// Étapes 1 → 2 en 5 min : il reste 2 étapes, soit ≈ 10 min.
+	in.At, in.Progress = etaT0, 1
+	if got := e.Estimate(in); got.Minutes != -1 {
+		t.Errorf("first poll = %+v, want unknown", got)
+	}
+	in.At, in.Progress = etaT0.Add(5*time.Minute), 2
+	got := e.Estimate(in)
+	if got.Minutes != 10 {
+		t.Errorf("estimate = %+v, want 10 min from progress", got)
+	}
+
+	// Sans nouvelle source exploitable, l'estimation décroît avec le temps.
+	in.At, in.TotalProgress = etaT0.Add(8*time.Minute), 0
+	decayed := e.Estimate(in)
+	if decayed.Minutes != 7 || decayed.Confidence >= got.Confidence {
+		t.Errorf("decayed = %+v, want 7 min with lower confidence", decayed)
+	}
+}
+
+func TestETAEstimator_TerminalPhase(t *testing.T) {
+	e := tracker.NewETAEstimator()
+	e.Estimate(tracker.ETAInput{At: etaT0, UberETA: 8, Phase: tracker.PhaseActive})
+
+	got := e.Estimate(tracker.ETAInput{At: etaT0.Add(time.Minute), UberETA: -1, Phase: tracker.PhaseDelivered})
+	if got.Minutes != 0 || got.Confidence != 1 {
+		t.Errorf("estimate = %+v, want 0 min, certain", got)
+	}
+}
+
+func TestETAEstimator_DistanceFromActiveOrder(t *testing.T) {
+	resp := testutil.LoadTestResponse(t, "order_full.json")
+	if phase := resp.Data.Orders[0].OrderStatus.OrderPhase; phase != "ACTIVE" {
+		t.Fatalf("fixture phase = %s, want ACTIVE", phase)
+	}
+	r, err := tracker.Reconcile(context.Background(), testutil.NewMockOrderStore(), "uuid-e", resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := tracker.ETAInput{
+		At:            etaT0,
+		UberETA:       -1,
+		Phase:         r.Phase,
+		Progress:      r.Progress,
+		TotalProgress: r.TotalProgress,
+		Motion:        r.Motion,
+		EnRoute:       r.EnRoute,
+	}
+
+	// ≈ 337 m × 1,3 à la vitesse par défaut (4 m/s) ≈ 2 min.
+	if got := tracker.NewETAEstimator().Estimate(in); got.Minutes != 2 || got.Confidence <= 0 {
+		t.Errorf("estimate = %+v, want 2 min from distance while ACTIVE", got)
+	}
+
+	// Avant l'enlèvement, la distance du livreur ne donne pas l'ETA.
+	in.EnRoute = false
+	if got := tracker.NewETAEstimator().Estimate(in); got.Minutes != -1 {
+		t.Errorf("estimate = %+v, want unknown when not en route", got)
+	}
+}
@@ -0,0 +1,115 @@
+// Package tracker_test — Tests Black Box pour le package tracker (événements).
+package tracker_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+// eventsByKind indexe les événements par type.
+func eventsByKind(events []tracker.Event) map[tracker.EventKind]tracker.Event {
+	out := make(map[tracker.EventKind]tracker.Event, len(events))
+	for _, e := range events {
+		out[e.Kind] = e
+	}
+	return out
+}
+
+func TestReconcile_FirstPollAnnouncesKnownFacts(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	resp := testutil.LoadTestResponse(t, "order_full.json")
+
+	result, err := tracker.Reconcile(context.Background(), store, "uuid-full", resp)
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+
+	got := eventsByKind(result.Events)
+	checks := []struct {
+		kind          tracker.EventKind
+		before, after string
+	}{
+		{tracker.EventPhaseChanged, "", "ACTIVE"},
+		{tracker.EventETAChanged, "-1", "7"},
+		{tracker.EventCourierAssigned, "", "courier"},
+		{tracker.EventPINAvailable, "", "4821"},
+		{tracker.EventAddressConfirmed, "", "12 Rue de Paradis, 75010 Paris"},
+	}
+	for _, c := range checks {
+		e, ok := got[c.kind]
+		if !ok {
+			t.Errorf("missing %s event in %+v", c.kind, result.Events)
+			continue
+		}
+		if e.Before != c.before || e.After != c.after || e.UUID != "uuid-full" {
+			t.Errorf("%s = %+v, want %q → %q", c.kind, e, c.before, c.after)
+		}
+	}
+	if _, ok := got[tracker.EventOrderCancelled]; ok {
+		t.Error("unexpected ORDER_CANCELLED on an active order")
+	}
+}
+
+func TestReconcile_DisguisedCancellationEvents(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	ctx := context.Background()
+
+	prev := testutil.LoadTestResponse(t, "order_active.json")
+	store.SeedSnapshot("uuid-c", "ACTIVE", 2, "En préparation", mustMarshalJSON(prev))
+
+	result, err := tracker.Reconcile(ctx, store, "uuid-c", testutil.LoadTestResponse(t, "order_cancelled.json"))
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+
+	got := eventsByKind(result.Events)
+	if e := got[tracker.EventPhaseChanged]; e.Before != "ACTIVE" || e.After != "CANCELLED" {
+		t.Errorf("PHASE_CHANGED = %+v, want ACTIVE → CANCELLED", e)
+	}
+	if e, ok := got[tracker.EventOrderCancelled]; !ok || e.Before != "ACTIVE" {
+		t.Errorf("ORDER_CANCELLED = %+v (present=%v), want Before ACTIVE", e, ok)
+	}
+	if _, ok := got[tracker.EventAddressConfirmed]; ok {
+		t.Error("address did not change, ADDRESS_CONFIRMED unexpected")
+	}
+}
+
+func TestReconcile_NoEventsWhenNothingChanged(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	ctx := context.Background()
+
+	resp := testutil.NewTestOrder().WithPhase("ACTIVE").WithProgress(2, 5).WithStatusText("En préparation").BuildResponse()
+	store.SeedSnapshot("uuid-same", "ACTIVE", 2, "En préparation", mustMarshalJSON(resp))
+
+	result, err := tracker.Reconcile(ctx, store, "uuid-same", resp)
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	if len(result.Events) != 0 {
+		t.Errorf("Events = %+v, want none", result.Events)
+	}
+}
+
+func TestReconcile_ProgressAdvanced(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	ctx := context.Background()
+
+	prev := testutil.NewTestOrder().WithPhase("ACTIVE").WithProgress(2, 5).BuildResponse()
+	store.SeedSnapshot("uuid-p", "ACTIVE", 2, "", mustMarshalJSON(prev))
+
+	next := testutil.NewTestOrder().WithPhase("EN_ROUTE").WithProgress(4, 5).BuildResponse()
+	result, err := tracker.Reconcile(ctx, store, "uuid-p", next)
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	got := eventsByKind(result.Events)
+	if e := got[tracker.EventProgressAdvanced]; e.Before != "2" || e.After != "4" {
+		t.Errorf("PROGRESS_ADVANCED = %+v, want 2 → 4", e)
+	}
+	if e := got[tracker.EventPhaseChanged]; e.After != "EN_ROUTE" {
+		t.Errorf("PHASE_CHANGED = %+v, want → EN_ROUTE", e)
+	}
+}
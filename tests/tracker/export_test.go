@@ -0,0 +1,122 @@
+// Package tracker_test — Tests Black Box pour le package tracker (export du trajet).
+package tracker_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+// deliveryRoute construit le trajet d'order_full.json avec une trace de deux positions.
+func deliveryRoute(t *testing.T) tracker.Route {
+	t.Helper()
+	at := time.Date(2024, 5, 1, 19, 30, 0, 0, time.UTC)
+	return tracker.RouteOf(tracker.TrackedOrder{
+		UUID:         "3f2a9c1e-7b4d",
+		FullJSONData: mustMarshalJSON(testutil.LoadTestResponse(t, "order_full.json")),
+		CourierTrail: []tracker.Position{
+			{Coordinates: tracker.Coordinates{Latitude: 48.8662, Longitude: 2.3522}, At: at, Phase: tracker.PhasePreparing, ETA: 15},
+			{Coordinates: tracker.Coordinates{Latitude: 48.8695, Longitude: 2.3468}, At: at.Add(2 * time.Minute), Phase: tracker.PhaseEnRoute, ETA: -1},
+		},
+	})
+}
+
+func TestRouteOf_ReadsFixedPoints(t *testing.T) {
+	r := deliveryRoute(t)
+	if r.Restaurant == nil || r.Restaurant.Latitude != 48.8662 {
+		t.Errorf("Restaurant = %+v", r.Restaurant)
+	}
+	if r.Destination == nil || r.Destination.Longitude != 2.3431 {
+		t.Errorf("Destination = %+v", r.Destination)
+	}
+}
+
+func TestRoute_GeoJSON(t *testing.T) {
+	data, err := deliveryRoute(t).GeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatalf("invalid GeoJSON: %v", err)
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 5 {
+		t.Fatalf("type=%s features=%d, want FeatureCollection with 5 features", fc.Type, len(fc.Features))
+	}
+
+	var line [][2]float64
+	for _, f := range fc.Features {
+		if f.Geometry.Type == "LineString" {
+			if err := json.Unmarshal(f.Geometry.Coordinates, &line); err != nil {
+				t.Fatal(err)
+			}
+			if phases, _ := f.Properties["phases"].([]any); len(phases) != 2 || phases[1] != "EN_ROUTE" {
+				t.Errorf("phases = %v", f.Properties["phases"])
+			}
+		}
+	}
+	if len(line) != 2 || line[0] != [2]float64{2.3522, 48.8662} {
+		t.Errorf("LineString = %v, want [lon, lat] pairs", line)
+	}
+}
+
+func TestRoute_GPX(t *testing.T) {
+	data, err := deliveryRoute(t).GPX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "<?xml") {
+		t.Error("missing XML header")
+	}
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Wpt     []struct {
+			Name string `xml:"name"`
+		} `xml:"wpt"`
+		Trkpt []struct {
+			Lat  float64 `xml:"lat,attr"`
+			Time string  `xml:"time"`
+			Type string  `xml:"type"`
+			Desc string  `xml:"desc"`
+		} `xml:"trk>trkseg>trkpt"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid GPX: %v", err)
+	}
+	if doc.Version != "1.1" || len(doc.Wpt) != 2 || len(doc.Trkpt) != 2 {
+		t.Fatalf("version=%s wpt=%d trkpt=%d", doc.Version, len(doc.Wpt), len(doc.Trkpt))
+	}
+	first := doc.Trkpt[0]
+	if first.Time != "2024-05-01T19:30:00Z" || first.Type != "PREPARING" || first.Desc != "ETA 15 min" {
+		t.Errorf("first trkpt = %+v", first)
+	}
+	if doc.Trkpt[1].Desc != "" {
+		t.Errorf("unknown ETA should have no desc, got %q", doc.Trkpt[1].Desc)
+	}
+}
+
+func TestRoute_EmptyExports(t *testing.T) {
+	data, err := tracker.Route{}.GeoJSON()
+	if err != nil || string(data) != `{"type":"FeatureCollection","features":[]}` {
+		t.Errorf("GeoJSON = %s, %v", data, err)
+	}
+	if _, err := (tracker.Route{}).GPX(); err != nil {
+		t.Errorf("GPX error: %v", err)
+	}
+}
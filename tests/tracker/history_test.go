@@ -0,0 +1,219 @@
+// Package tracker_test — Tests Black Box pour le package tracker (historique JSON Patch).
+package tracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+// assertSameJSON compare deux documents JSON par leurs valeurs.
+func assertSameJSON(t *testing.T, got, want []byte) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("got: %v (%s)", err, got)
+	}
+	if err := json.Unmarshal(want, &w); err != nil {
+		t.Fatalf("want: %v", err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+}
+
+func TestDiffJSON_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+	}{
+		{"first snapshot", ``, `{"a":1}`},
+		{"unchanged", `{"a":[1,2]}`, `{"a":[1,2]}`},
+		{"replace scalar", `{"a":1,"b":"x"}`, `{"a":2,"b":"x"}`},
+		{"add and remove keys", `{"a":1,"gone":true}`, `{"a":1,"new":{"x":null}}`},
+		{"array grows", `{"l":[1,2]}`, `{"l":[1,2,3,{"k":"v"}]}`},
+		{"array shrinks", `{"l":[1,2,3,4]}`, `{"l":[9]}`},
+		{"nested change", `{"o":{"p":[{"q":1},{"q":2}]}}`, `{"o":{"p":[{"q":1},{"q":3,"r":4}]}}`},
+		{"type change", `{"a":{"b":1}}`, `{"a":[1]}`},
+		{"escaped keys", `{"a/b":1,"m~n":2}`, `{"a/b":3,"m~n":4}`},
+		{"large number", `{"n":12345678901234567890}`, `{"n":12345678901234567891}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := tracker.DiffJSON([]byte(tt.before), []byte(tt.after))
+			if err != nil {
+				t.Fatalf("DiffJSON: %v", err)
+			}
+			if tt.before == tt.after && len(patch) != 0 {
+				t.Errorf("patch = %+v, want empty", patch)
+			}
+			got, err := tracker.ApplyPatch([]byte(tt.before), patch)
+			if err != nil {
+				t.Fatalf("ApplyPatch(%+v): %v", patch, err)
+			}
+			assertSameJSON(t, got, []byte(tt.after))
+		})
+	}
+}
+
+func TestApplyPatch_RFC6902(t *testing.T) {
+	// Exemples de l'annexe A de la RFC 6902.
+	var patch tracker.Patch
+	if err := json.Unmarshal([]byte(`[
+		{"op":"add","path":"/foo/1","value":"qux"},
+		{"op":"remove","path":"/baz"},
+		{"op":"add","path":"/foo/-","value":"end"},
+		{"op":"test","path":"/foo/0","value":"bar"},
+		{"op":"replace","path":"/hello","value":["world"]}
+	]`), &patch); err != nil {
+		t.Fatal(err)
+	}
+	got, err := tracker.ApplyPatch([]byte(`{"foo":["bar","baz"],"baz":"qux","hello":1}`), patch)
+	if err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	assertSameJSON(t, got, []byte(`{"foo":["bar","qux","baz","end"],"hello":["world"]}`))
+
+	bad := []tracker.Patch{
+		{{Op: "remove", Path: "/missing"}},
+		{{Op: "replace", Path: "/foo/5", Value: json.RawMessage(`1`)}},
+		{{Op: "test", Path: "/hello", Value: json.RawMessage(`2`)}},
+		{{Op: "move", Path: "/x"}},
+		{{Op: "add", Path: "nope", Value: json.RawMessage(`1`)}},
+	}
+	for _, p := range bad {
+		if _, err := tracker.ApplyPatch([]byte(`{"foo":[],"hello":1}`), p); !errors.Is(err, tracker.ErrInvalidPatch) {
+			t.Errorf("ApplyPatch(%+v) error = %v, want ErrInvalidPatch", p, err)
+		}
+	}
+}
+
+func TestReplayHistory_DeliveryCassette(t *testing.T) {
+	ctx := context.Background()
+	store := testutil.NewMockOrderStore()
+
+	snapshots := map[time.Time]string{}
+	for _, it := range testutil.LoadTestCassette(t, "cassette_delivery.jsonl") {
+		if it.Status != 200 {
+			continue
+		}
+		var resp tracker.Response
+		if err := json.Unmarshal(it.Body, &resp); err != nil {
+			t.Fatal(err)
+		}
+		r, err := tracker.Reconcile(ctx, store, cassetteUUID, resp)
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		if err := store.SaveOrder(ctx, tracker.TrackedOrder{
+			UUID: cassetteUUID, LastStatus: string(r.Phase), LastProgress: r.Progress,
+			LastText: r.Text, FullJSONData: r.FinalJSON,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Patch) > 0 {
+			_ = store.AppendPatch(ctx, cassetteUUID, tracker.HistoryEntry{At: it.Time, Patch: r.Patch})
+		}
+		snapshots[it.Time] = r.FinalJSON
+	}
+
+	history, _ := store.GetHistory(ctx, cassetteUUID)
+	for at, want := range snapshots {
+		got, err := tracker.ReplayHistory(history, at)
+		if err != nil {
+			t.Fatalf("ReplayHistory(%v): %v", at, err)
+		}
+		assertSameJSON(t, got, []byte(want))
+	}
+
+	// Entre deux polls : le snapshot du poll précédent.
+	start := time.Date(2025, 3, 14, 19, 40, 0, 0, time.UTC)
+	got, _ := tracker.ReplayHistory(history, start.Add(10*time.Second))
+	assertSameJSON(t, got, []byte(snapshots[start]))
+
+	if got, _ := tracker.ReplayHistory(history, start.Add(-time.Minute)); got != nil {
+		t.Errorf("before the first poll = %s, want nil", got)
+	}
+}
+
+func TestWorker_RecordsHistoryBaseline(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueResponse(testutil.LoadTestJSON(t, "order_full.json"), nil)
+
+	mgr := tracker.NewManager(store, mockFetch.Fn())
+	defer mgr.Shutdown()
+	mgr.StartTracking(tracker.OrderIdentity{UUID: "uuid-full", ChannelID: "ch-1", GuildID: "g1"})
+
+	var update tracker.TrackedOrder
+	select {
+	case update = <-mgr.UpdateChannel:
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected an update")
+	}
+
+	history, _ := store.GetHistory(context.Background(), "uuid-full")
+	if len(history) != 1 || len(history[0].Patch) != 1 || history[0].Patch[0].Path != "" {
+		t.Fatalf("history = %+v, want one full-document baseline", history)
+	}
+
+	resp, err := tracker.RebuildOrder(context.Background(), store, "uuid-full", time.Now())
+	if err != nil {
+		t.Fatalf("RebuildOrder: %v", err)
+	}
+	rebuilt, _ := json.Marshal(resp)
+	assertSameJSON(t, rebuilt, []byte(update.FullJSONData))
+
+	if _, err := tracker.RebuildOrder(context.Background(), store, "uuid-full", update.LastUpdated.Add(-time.Hour)); !errors.Is(err, tracker.ErrOrderNotFound) {
+		t.Errorf("RebuildOrder before tracking error = %v, want ErrOrderNotFound", err)
+	}
+}
+
+// pollOnce suit uuid avec un Manager neuf sur store jusqu'à sa première
+// mise à jour, puis l'arrête.
+func pollOnce(t *testing.T, store *testutil.MockOrderStore, uuid string, data []byte) tracker.TrackedOrder {
+	t.Helper()
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueResponse(data, nil)
+	mgr := tracker.NewManager(store, mockFetch.Fn())
+	defer mgr.Shutdown()
+	mgr.StartTracking(tracker.OrderIdentity{UUID: uuid, ChannelID: "ch-1", GuildID: "g1"})
+	select {
+	case u := <-mgr.UpdateChannel:
+		return u
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected an update")
+		return tracker.TrackedOrder{}
+	}
+}
+
+func TestWorker_LostPatchRestartsHistoryFromBaseline(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	pollOnce(t, store, "uuid-h", testutil.LoadTestJSON(t, "order_active.json"))
+
+	// Le snapshot avance mais son patch est perdu.
+	store.AppendErr = errors.New("disque plein")
+	pollOnce(t, store, "uuid-h", testutil.LoadTestJSON(t, "order_with_pin.json"))
+	if history, _ := store.GetHistory(context.Background(), "uuid-h"); len(history) != 1 {
+		t.Fatalf("history = %d entries, want 1 (patch lost)", len(history))
+	}
+
+	update := pollOnce(t, store, "uuid-h", testutil.LoadTestJSON(t, "order_completed.json"))
+	history, _ := store.GetHistory(context.Background(), "uuid-h")
+	last := history[len(history)-1]
+	if len(last.Patch) != 1 || last.Patch[0].Path != "" {
+		t.Errorf("last patch = %+v, want a full-document baseline after the lost patch", last.Patch)
+	}
+	resp, err := tracker.RebuildOrder(context.Background(), store, "uuid-h", time.Now())
+	if err != nil {
+		t.Fatalf("RebuildOrder: %v", err)
+	}
+	rebuilt, _ := json.Marshal(resp)
+	assertSameJSON(t, rebuilt, []byte(update.FullJSONData))
+}
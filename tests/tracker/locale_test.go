@@ -0,0 +1,155 @@
+// Package tracker_test — Tests Black Box pour le package tracker (packs de langue).
+package tracker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+	"github.com/superselle/ubertracker/tracker/trackertest"
+)
+
+func TestLocalePackFor(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"fr", "fr"},
+		{"en", "en"},
+		{"en-US", "en"},
+		{"fr_CA", "fr"},
+		{"EN-gb", "en"},
+		{"xx", "fr"}, // inconnu → pack par défaut
+		{"", "fr"},
+	}
+	for _, tt := range tests {
+		if got := tracker.LocalePackFor(tt.code).Code; got != tt.want {
+			t.Errorf("LocalePackFor(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestWithMergeLocale_DisguisedCancellation(t *testing.T) {
+	tests := []struct {
+		name  string
+		pack  tracker.LocalePack
+		title string
+		want  tracker.OrderPhase
+	}{
+		{"fr annulée", tracker.LocaleFrench, "Commande annulée", tracker.PhaseCancelled},
+		{"en cancelled", tracker.LocaleEnglish, "Your order was cancelled", tracker.PhaseCancelled},
+		{"en canceled", tracker.LocaleEnglish, "Order Canceled", tracker.PhaseCancelled},
+		{"en ignores fr keyword", tracker.LocaleEnglish, "Commande annulée", tracker.PhaseCompleted},
+		{"fr ignores en keyword", tracker.LocaleFrench, "Order cancelled", tracker.PhaseCompleted},
+		{"en unrelated CTA", tracker.LocaleEnglish, "Rate your order", tracker.PhaseCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := testutil.NewTestOrder().WithCallToAction(tt.title).Build()
+			next.OrderStatus.OrderPhase = "COMPLETED"
+
+			_, phase := tracker.MergeOrderData(testutil.NewTestOrder().Build(), next, true, tracker.WithMergeLocale(tt.pack))
+			if phase != tt.want {
+				t.Errorf("phase = %q, want %q", phase, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithMergeLocale_DeliveredTexts(t *testing.T) {
+	for _, pack := range []tracker.LocalePack{tracker.LocaleFrench, tracker.LocaleEnglish} {
+		t.Run(pack.Code, func(t *testing.T) {
+			prev := testutil.NewTestOrder().WithProgress(3, 5).WithStatusText("En livraison").Build()
+			next := testutil.NewTestOrder().Build()
+			next.OrderStatus.OrderPhase = "COMPLETED"
+
+			merged, _ := tracker.MergeOrderData(prev, next, true, tracker.WithMergeLocale(pack))
+			status := merged.FeedCards[0].Status
+			if status.Title != pack.DeliveredTitle ||
+				status.TitleSummary.Summary.Text != pack.DeliveredSummary ||
+				status.StatusSummary.Text != pack.DeliveredStatus {
+				t.Errorf("status texts = %q / %q / %q, want the %s pack",
+					status.Title, status.TitleSummary.Summary.Text, status.StatusSummary.Text, pack.Code)
+			}
+		})
+	}
+
+	// MergeOrderData conserve le comportement historique (français).
+	next := testutil.NewTestOrder().Build()
+	next.OrderStatus.OrderPhase = "COMPLETED"
+	merged, _ := tracker.MergeOrderData(testutil.NewTestOrder().WithProgress(3, 5).Build(), next, true)
+	if got := merged.FeedCards[0].Status.Title; got != "Commande Livrée" {
+		t.Errorf("default title = %q, want Commande Livrée", got)
+	}
+}
+
+func TestClient_LocalePack(t *testing.T) {
+	if got := tracker.NewClient().LocalePack().Code; got != "fr" {
+		t.Errorf("default client pack = %q, want fr", got)
+	}
+	if got := tracker.NewClient(tracker.WithLocale("en-US")).LocalePack().Code; got != "en" {
+		t.Errorf("en-US client pack = %q, want en", got)
+	}
+
+	custom := tracker.LocaleEnglish
+	custom.Code = "en-custom"
+	c := tracker.NewClient(tracker.WithLocale("fr"), tracker.WithLocalePack(custom))
+	if got := c.LocalePack().Code; got != "en-custom" {
+		t.Errorf("explicit pack = %q, want en-custom", got)
+	}
+}
+
+func TestManager_LocalePackAppliesToAbandonText(t *testing.T) {
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueResponse([]byte(`{"data":{"orders":[]}}`), nil)
+
+	mgr := tracker.NewManager(testutil.NewMockOrderStore(), mockFetch.Fn()).
+		WithLocalePack(tracker.LocaleEnglish).
+		WithMissTolerance(1)
+	defer mgr.Shutdown()
+	mgr.StartTracking(tracker.OrderIdentity{UUID: "uuid-gone", ChannelID: "ch-1", GuildID: "g1"})
+
+	select {
+	case u := <-mgr.UpdateChannel:
+		if u.LastText != tracker.LocaleEnglish.OrderNotFound {
+			t.Errorf("LastText = %q, want %q", u.LastText, tracker.LocaleEnglish.OrderNotFound)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected a FAILED update")
+	}
+}
+
+func TestManager_LocalePackPerClient(t *testing.T) {
+	srv := trackertest.NewServer() // aucune commande : chaque poll est une absence
+	defer srv.Close()
+	english := srv.Client(tracker.WithName("en"), tracker.WithLocale("en-US"))
+	french := srv.Client(tracker.WithName("fr"), tracker.WithLocale("fr"))
+
+	// Chaque commande est rattachée à l'une des deux sessions ; la fenêtre de
+	// regroupement garde la première rattachée pendant que la seconde démarre.
+	poller := tracker.NewBatchPoller([]tracker.BatchFetchFn{english.FetchBatch, french.FetchBatch},
+		tracker.WithBatchWindow(200*time.Millisecond))
+	mgr := tracker.NewManager(testutil.NewMockOrderStore()).
+		WithBatchPolling(poller).
+		WithLocalePack(tracker.LocaleEnglish). // repli, ignoré pour les réponses d'un Client
+		WithMissTolerance(1)
+	defer mgr.Shutdown()
+	mgr.StartTracking(tracker.OrderIdentity{UUID: "uuid-1", ChannelID: "ch-1", GuildID: "g1"})
+	mgr.StartTracking(tracker.OrderIdentity{UUID: "uuid-2", ChannelID: "ch-1", GuildID: "g1"})
+
+	texts := map[string]bool{}
+	for range 2 {
+		select {
+		case u := <-mgr.UpdateChannel:
+			texts[u.LastText] = true
+		case <-time.After(3 * time.Second):
+			t.Fatal("expected two FAILED updates")
+		}
+	}
+	for _, want := range []string{tracker.LocaleEnglish.OrderNotFound, tracker.LocaleFrench.OrderNotFound} {
+		if !texts[want] {
+			t.Errorf("texts = %v, want %q among them", texts, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Package tracker_test — Tests Black Box pour le package tracker (déplacement du livreur).
+package tracker_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+func TestCoordinates_DistanceTo(t *testing.T) {
+	paris := tracker.Coordinates{Latitude: 48.8566, Longitude: 2.3522}
+	london := tracker.Coordinates{Latitude: 51.5074, Longitude: -0.1278}
+
+	if d := paris.DistanceTo(london); math.Abs(d-343556) > 100 {
+		t.Errorf("Paris → London = %.0f m, want ≈ 343556 m", d)
+	}
+	if d := paris.DistanceTo(paris); d != 0 {
+		t.Errorf("distance to self = %f, want 0", d)
+	}
+}
+
+func TestMotionTracker_SpeedAndStall(t *testing.T) {
+	m := tracker.NewMotionTracker(3 * time.Minute)
+	dest := &tracker.Coordinates{Latitude: 48.8713, Longitude: 2.3431}
+	t0 := time.Date(2024, 5, 1, 19, 30, 0, 0, time.UTC)
+
+	start := &tracker.Coordinates{Latitude: 48.8600, Longitude: 2.3500}
+	first := m.Observe(start, dest, true, t0)
+	if first.Speed != 0 || first.Stalled || first.DistanceRemaining <= 0 {
+		t.Errorf("first observation = %+v", first)
+	}
+
+	// ≈ 111 m vers le nord en 20 s → ≈ 5,6 m/s.
+	moved := &tracker.Coordinates{Latitude: 48.8610, Longitude: 2.3500}
+	second := m.Observe(moved, dest, true, t0.Add(20*time.Second))
+	if math.Abs(second.Speed-5.56) > 0.1 || second.SmoothedSpeed != second.Speed {
+		t.Errorf("speed = %.2f (smoothed %.2f), want ≈ 5.56", second.Speed, second.SmoothedSpeed)
+	}
+	if second.DistanceRemaining >= first.DistanceRemaining {
+		t.Errorf("distance remaining did not shrink: %.0f → %.0f", first.DistanceRemaining, second.DistanceRemaining)
+	}
+
+	// Petits écarts GPS (< 25 m) : le livreur est considéré immobile.
+	jitter := &tracker.Coordinates{Latitude: 48.86105, Longitude: 2.3500}
+	third := m.Observe(jitter, dest, true, t0.Add(2*time.Minute))
+	if third.Stalled || third.SmoothedSpeed >= second.SmoothedSpeed {
+		t.Errorf("after 100 s still = %+v, want not stalled and slowing down", third)
+	}
+	fourth := m.Observe(jitter, dest, true, t0.Add(4*time.Minute))
+	if !fourth.Stalled || !fourth.StallStarted || fourth.StillFor != 3*time.Minute+40*time.Second {
+		t.Errorf("after 220 s still = %+v, want stalled", fourth)
+	}
+	if fifth := m.Observe(jitter, dest, true, t0.Add(4*time.Minute+30*time.Second)); !fifth.Stalled || fifth.StallStarted {
+		t.Errorf("still stalled = %+v, want Stalled without a new StallStarted", fifth)
+	}
+
+	// Livreur pas en route (ex : attente au restaurant) : pas de blocage signalé.
+	if waiting := m.Observe(jitter, dest, false, t0.Add(5*time.Minute)); waiting.Stalled {
+		t.Error("courier waiting at the restaurant must not be stalled")
+	}
+}
+
+func TestMotionTracker_UnknownPosition(t *testing.T) {
+	m := tracker.NewMotionTracker(0)
+	if got := m.Observe(nil, nil, true, time.Now()); got.DistanceRemaining != -1 || got.Stalled {
+		t.Errorf("Observe(nil) = %+v, want unknown distance", got)
+	}
+}
+
+func TestReconcile_DistanceRemaining(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	result, err := tracker.Reconcile(context.Background(), store, "uuid-d", testutil.LoadTestResponse(t, "order_full.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := result.Motion.DistanceRemaining; math.Abs(d-336.6) > 1 {
+		t.Errorf("DistanceRemaining = %.1f m, want ≈ 336.6 m", d)
+	}
+}
+
+func TestReconcile_ActiveOrderWithCourierIsEnRoute(t *testing.T) {
+	resp := testutil.LoadTestResponse(t, "order_active.json")
+	if phase := resp.Data.Orders[0].OrderStatus.OrderPhase; phase != "ACTIVE" {
+		t.Fatalf("fixture phase = %s, want ACTIVE", phase)
+	}
+	r, err := tracker.Reconcile(context.Background(), testutil.NewMockOrderStore(), "uuid-r", resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.EnRoute || r.Courier == nil {
+		t.Fatalf("EnRoute = %v (courier %v), want en route from progress %d/%d", r.EnRoute, r.Courier, r.Progress, r.TotalProgress)
+	}
+
+	// Un livreur immobile est signalé bloqué une seule fois, en phase ACTIVE.
+	m := tracker.NewMotionTracker(3 * time.Minute)
+	t0 := time.Date(2024, 5, 1, 19, 30, 0, 0, time.UTC)
+	var started int
+	for i := range 6 {
+		motion := m.Observe(r.Courier, nil, r.EnRoute, t0.Add(time.Duration(i)*time.Minute))
+		if motion.StallStarted {
+			started++
+		}
+		if want := i >= 3; motion.Stalled != want {
+			t.Errorf("minute %d: Stalled = %v, want %v", i, motion.Stalled, want)
+		}
+	}
+	if started != 1 {
+		t.Errorf("StallStarted %d times, want 1", started)
+	}
+}
+
+func TestReconcile_ActiveOrderBeforePickupIsNotEnRoute(t *testing.T) {
+	r, err := tracker.Reconcile(context.Background(), testutil.NewMockOrderStore(), "uuid-p", testutil.LoadTestResponse(t, "order_no_eta.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.EnRoute {
+		t.Errorf("EnRoute = true at progress %d/%d, want false before pickup", r.Progress, r.TotalProgress)
+	}
+}
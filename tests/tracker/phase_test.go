@@ -0,0 +1,108 @@
+// Package tracker_test — Tests Black Box pour le package tracker (phases).
+package tracker_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+func TestOrderPhase_IsTerminal(t *testing.T) {
+	tests := []struct {
+		phase tracker.OrderPhase
+		want  bool
+	}{
+		{tracker.PhaseActive, false},
+		{tracker.PhasePreparing, false},
+		{tracker.PhaseCourierAssigned, false},
+		{tracker.PhaseEnRoute, false},
+		{tracker.PhaseDelivered, true},
+		{tracker.PhaseCompleted, true},
+		{tracker.PhaseCancelled, true},
+		{tracker.PhaseFailed, true},
+		{"SOMETHING_NEW", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := tt.phase.IsTerminal(); got != tt.want {
+			t.Errorf("%q.IsTerminal() = %v, want %v", tt.phase, got, tt.want)
+		}
+	}
+}
+
+func TestOrderPhase_Transitions(t *testing.T) {
+	tests := []struct {
+		from, to tracker.OrderPhase
+		want     bool
+	}{
+		{tracker.PhasePreparing, tracker.PhaseEnRoute, true},
+		{tracker.PhaseEnRoute, tracker.PhaseCompleted, true},
+		{tracker.PhaseCompleted, tracker.PhaseCancelled, true},
+		{tracker.PhaseActive, tracker.PhaseActive, true},
+		{tracker.PhaseEnRoute, tracker.PhaseFailed, true},
+		{tracker.PhaseCompleted, tracker.PhasePreparing, false},
+		{tracker.PhaseCancelled, tracker.PhaseActive, false},
+		{tracker.PhaseEnRoute, tracker.PhasePreparing, false},
+		{"SOMETHING_NEW", tracker.PhasePreparing, true},
+		{tracker.PhaseCompleted, "SOMETHING_NEW", true},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s → %s = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	err := tracker.ValidateTransition(tracker.PhaseCompleted, tracker.PhasePreparing)
+	if !errors.Is(err, tracker.ErrInvalidTransition) {
+		t.Errorf("ValidateTransition err = %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestParsePhase_UnknownKeepsRawValue(t *testing.T) {
+	p := tracker.ParsePhase("DRONE_LANDING")
+	if p != "DRONE_LANDING" || p.Known() {
+		t.Errorf("ParsePhase = %q (known=%v), want raw unknown value", p, p.Known())
+	}
+	if !tracker.ParsePhase("EN_ROUTE").Known() {
+		t.Error("EN_ROUTE should be known")
+	}
+}
+
+func TestParsePhase_WarnsOncePerUnknownValue(t *testing.T) {
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(prev)
+
+	for range 3 {
+		tracker.ParsePhase("HOVERBOARD_PICKUP")
+	}
+	tracker.ParsePhase("HOVERBOARD_DROPOFF")
+
+	if got := strings.Count(logs.String(), "phase de commande inconnue"); got != 2 {
+		t.Errorf("warnings = %d, want 2 (one per distinct value):\n%s", got, logs.String())
+	}
+}
+
+func TestReconcile_IgnoresImpossibleTransition(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	ctx := context.Background()
+
+	prev := testutil.NewTestOrder().WithPhase("COMPLETED").WithProgress(5, 5).BuildResponse()
+	store.SeedSnapshot("uuid-stale", "COMPLETED", 5, "Livraison terminée", mustMarshalJSON(prev))
+
+	stale := testutil.NewTestOrder().WithPhase("PREPARING").WithProgress(2, 5).BuildResponse()
+	result, err := tracker.Reconcile(ctx, store, "uuid-stale", stale)
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	if result.Phase != tracker.PhaseCompleted {
+		t.Errorf("Phase = %q, want COMPLETED kept", result.Phase)
+	}
+}
@@ -0,0 +1,82 @@
+// Package tracker_test — Tests Black Box pour le package tracker (positions).
+package tracker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+func TestExtractMapPositions(t *testing.T) {
+	o := testutil.LoadTestResponse(t, "order_full.json").Data.Orders[0]
+
+	tests := []struct {
+		name    string
+		extract func(tracker.Order) (tracker.Coordinates, bool)
+		want    tracker.Coordinates
+	}{
+		{"courier", tracker.ExtractCourierPosition, tracker.Coordinates{Latitude: 48.8695, Longitude: 2.3468}},
+		{"restaurant", tracker.ExtractRestaurantPosition, tracker.Coordinates{Latitude: 48.8662, Longitude: 2.3522}},
+		{"destination", tracker.ExtractDestination, tracker.Coordinates{Latitude: 48.8713, Longitude: 2.3431}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.extract(o)
+			if !ok || got != tt.want {
+				t.Errorf("got (%+v, %v), want (%+v, true)", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractCourierPosition_Missing(t *testing.T) {
+	o := testutil.LoadTestResponse(t, "order_minimal.json").Data.Orders[0]
+	if c, ok := tracker.ExtractCourierPosition(o); ok {
+		t.Errorf("got %+v, want no courier on a minimal order", c)
+	}
+
+	zero := testutil.NewTestOrder().WithCourierPosition(0, 0).Build()
+	if _, ok := tracker.ExtractCourierPosition(zero); ok {
+		t.Error("(0, 0) must be treated as unknown")
+	}
+}
+
+func TestWorker_RecordsCourierTrail(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Trace persistée avant un redémarrage.
+	start := tracker.Position{Coordinates: tracker.Coordinates{Latitude: 48.8662, Longitude: 2.3522}, At: time.Now().Add(-time.Minute)}
+	if err := store.AppendCourierPosition(ctx, "uuid-trail", start); err != nil {
+		t.Fatal(err)
+	}
+
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueOrder(testutil.NewTestOrder().WithPhase("COMPLETED").WithCourierPosition(48.8713, 2.3431).Build())
+	updates := make(chan tracker.TrackedOrder, 10)
+
+	id := tracker.OrderIdentity{UUID: "uuid-trail", ChannelID: "ch-1"}
+	tracker.StartOrderWorker(ctx, store, id, updates, mockFetch.Fn())
+
+	var u tracker.TrackedOrder
+	select {
+	case u = <-updates:
+	default:
+		t.Fatal("expected an update")
+	}
+	if len(u.CourierTrail) != 2 || u.CourierTrail[0] != start {
+		t.Fatalf("CourierTrail = %+v, want resumed point + new point", u.CourierTrail)
+	}
+	if got := u.CourierTrail[1].Coordinates; got != (tracker.Coordinates{Latitude: 48.8713, Longitude: 2.3431}) {
+		t.Errorf("last point = %+v", got)
+	}
+
+	persisted, _ := store.GetCourierTrail(ctx, "uuid-trail")
+	if len(persisted) != 2 {
+		t.Errorf("persisted trail = %d points, want 2", len(persisted))
+	}
+}
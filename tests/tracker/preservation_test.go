@@ -0,0 +1,233 @@
+// Package tracker_test — Tests Black Box pour le package tracker (règles de préservation).
+package tracker_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+func deliveryAddress(o tracker.Order) string {
+	for _, fc := range o.FeedCards {
+		if fc.Delivery != nil && fc.Delivery.Address != "" {
+			return fc.Delivery.Address
+		}
+	}
+	return ""
+}
+
+func TestMergeOrderData_DefaultRulesPreserveLostFields(t *testing.T) {
+	prev := testutil.NewTestOrder().
+		WithProgress(2, 5).
+		WithSummary(tracker.OrderSummary{Total: "24,90 €", Tip: "2,00 €", CurrencyCode: "EUR"}).
+		WithAddress("12 rue de la Paix").
+		WithPIN("4821").
+		WithCourierProfile(tracker.CourierInfo{Name: "Karim", Rating: 4.9}).
+		Build()
+	next := testutil.NewTestOrder().WithProgress(3, 5).Build()
+
+	merged, _ := tracker.MergeOrderData(prev, next, true)
+
+	fc := merged.FeedCards[0]
+	if fc.Status.CurrentProgress != 3 {
+		t.Errorf("progress = %d, want 3", fc.Status.CurrentProgress)
+	}
+	if fc.OrderSummary.Total != "24,90 €" || fc.OrderSummary.Tip != "2,00 €" || fc.OrderSummary.CurrencyCode != "EUR" {
+		t.Errorf("summary = %+v, want preserved", fc.OrderSummary)
+	}
+	if got := deliveryAddress(merged); got != "12 rue de la Paix" {
+		t.Errorf("address = %q, want preserved", got)
+	}
+	if len(fc.Courier) != 1 || fc.Courier[0].PinInfo.Pin != "4821" || fc.Courier[0].Name != "Karim" || fc.Courier[0].Rating != 4.9 {
+		t.Errorf("courier = %+v, want PIN and profile preserved", fc.Courier)
+	}
+}
+
+func TestMergeOrderData_RestoresOnlyIntoExistingCards(t *testing.T) {
+	// La réponse porte une carte sans statut, ignorée par la fusion : les
+	// règles ne doivent pas la recréer dans l'Order fusionné.
+	next := tracker.Order{FeedCards: []tracker.FeedCard{{
+		Delivery:     &tracker.DeliveryInfo{Address: "12 rue de la Paix"},
+		OrderSummary: tracker.OrderSummary{Total: "24,90 €"},
+	}}}
+
+	merged, _ := tracker.MergeOrderData(tracker.Order{}, next, false)
+	for _, fc := range merged.FeedCards {
+		if fc.Status == nil {
+			t.Fatalf("FeedCards = %+v, want no card without a status", merged.FeedCards)
+		}
+	}
+}
+
+func TestWithMergeRules_Strategies(t *testing.T) {
+	prev := testutil.NewTestOrder().WithAddress("12 rue de la Paix").Build()
+	next := testutil.NewTestOrder().WithAddress("3 avenue Foch").Build()
+
+	latest, _ := tracker.MergeOrderData(prev, next, true)
+	if got := deliveryAddress(latest); got != "3 avenue Foch" {
+		t.Errorf("LatestWins address = %q, want %q", got, "3 avenue Foch")
+	}
+
+	rules := tracker.DefaultPreservationRules().WithRules(tracker.PreservationRule{
+		Path:     "/feedCards/*/delivery/formattedAddress",
+		Strategy: tracker.FirstWins,
+	})
+	first, _ := tracker.MergeOrderData(prev, next, true, tracker.WithMergeRules(rules))
+	if got := deliveryAddress(first); got != "12 rue de la Paix" {
+		t.Errorf("FirstWins address = %q, want %q", got, "12 rue de la Paix")
+	}
+	if len(rules) != len(tracker.DefaultPreservationRules()) {
+		t.Errorf("len(rules) = %d, want the default rule replaced", len(rules))
+	}
+}
+
+func TestWithMergeRules_ClearOn(t *testing.T) {
+	prev := testutil.NewTestOrder().WithProgress(4, 5).WithPIN("4821").Build()
+	next := testutil.NewTestOrder().WithPhase("COMPLETED").Build()
+	next.FeedCards = nil
+
+	kept, _ := tracker.MergeOrderData(prev, next, true)
+	if kept.FeedCards[0].Courier[0].PinInfo.Pin != "4821" {
+		t.Fatalf("default rules: PIN = %+v, want kept", kept.FeedCards[0].Courier)
+	}
+
+	rules := tracker.DefaultPreservationRules().WithRules(tracker.PreservationRule{
+		Path:    "/feedCards/*/courier/*/pinVerificationInfo/pin",
+		ClearOn: []tracker.OrderPhase{tracker.PhaseCompleted},
+	})
+	prev = testutil.NewTestOrder().WithProgress(4, 5).WithPIN("4821").Build()
+	merged, phase := tracker.MergeOrderData(prev, next, true, tracker.WithMergeRules(rules))
+	if phase != tracker.PhaseCompleted {
+		t.Fatalf("phase = %s, want COMPLETED", phase)
+	}
+	for _, c := range merged.FeedCards[0].Courier {
+		if c.PinInfo.Pin != "" {
+			t.Errorf("PIN = %q, want cleared once delivered", c.PinInfo.Pin)
+		}
+	}
+}
+
+func TestWithMergeRules_RestaurantLocation(t *testing.T) {
+	prev := testutil.NewTestOrder().WithCourierPosition(48.85, 2.35).WithRestaurantPosition(48.86, 2.34).Build()
+	next := testutil.NewTestOrder().WithCourierPosition(48.851, 2.351).Build()
+
+	merged, _ := tracker.MergeOrderData(prev, next, true)
+	if _, ok := tracker.ExtractRestaurantPosition(merged); ok {
+		t.Fatal("default rules should not keep the restaurant location")
+	}
+
+	rules := tracker.DefaultPreservationRules().WithRules(tracker.PreservationRule{
+		Path: "/backgroundFeedCards/*/mapEntity/[type=RESTAURANT]",
+	})
+	merged, _ = tracker.MergeOrderData(prev, next, true, tracker.WithMergeRules(rules))
+	if pos, ok := tracker.ExtractRestaurantPosition(merged); !ok || pos.Latitude != 48.86 || pos.Longitude != 2.34 {
+		t.Errorf("restaurant = %+v (%v), want preserved", pos, ok)
+	}
+	if pos, _ := tracker.ExtractCourierPosition(merged); pos.Latitude != 48.851 {
+		t.Errorf("courier = %+v, want the latest position", pos)
+	}
+
+	// Première réponse sans restaurant : rien à restaurer.
+	fresh, _ := tracker.MergeOrderData(next, next, false, tracker.WithMergeRules(rules))
+	if _, ok := tracker.ExtractRestaurantPosition(fresh); ok {
+		t.Error("restaurant restored without ever being observed")
+	}
+}
+
+func TestWithMergeRules_ItemDetailsByTitle(t *testing.T) {
+	prev := testutil.NewTestOrder().
+		WithItems(
+			tracker.Item{Title: "Classic Burger", Quantity: 1, Price: "8,50 €", SpecialInstructions: "sans oignons"},
+			tracker.Item{Title: "Frites maison", Quantity: 1, Price: "3,90 €"},
+		).
+		Build()
+	// Uber ne renvoie plus qu'un article, sans ses détails.
+	next := testutil.NewTestOrder().WithItems(tracker.Item{Title: "Frites maison", Quantity: 1}).Build()
+
+	merged, _ := tracker.MergeOrderData(prev, next, true)
+	items := merged.ActiveOrderOverview.Items
+	if len(items) != 1 || items[0].Price != "3,90 €" {
+		t.Errorf("items = %+v, want the fries with their own price", items)
+	}
+
+	// Sans les règles d'articles, seuls les détails renvoyés par Uber restent.
+	var rules tracker.PreservationRules
+	for _, r := range tracker.DefaultPreservationRules() {
+		if !strings.HasPrefix(r.Path, "/activeOrderOverview/items") {
+			rules = append(rules, r)
+		}
+	}
+	merged, _ = tracker.MergeOrderData(prev, next, true, tracker.WithMergeRules(rules))
+	if items := merged.ActiveOrderOverview.Items; len(items) != 1 || items[0].Price != "" {
+		t.Errorf("items = %+v, want no restored price", items)
+	}
+}
+
+func TestWithMergeRules_ContactsByRole(t *testing.T) {
+	prev := testutil.NewTestOrder().
+		WithRestaurant("Burger Palace").
+		WithContact("Karim", "+33 6 12 34 56 78").
+		WithContact("Burger Palace", "+33 1 42 00 00 00").
+		Build()
+	next := testutil.NewTestOrder().
+		WithRestaurant("Burger Palace").
+		WithContact("Burger Palace", "+33 1 42 00 00 00").
+		Build()
+
+	// Sans sa règle, le livreur disparu des contacts n'est pas restauré.
+	var rules tracker.PreservationRules
+	for _, r := range tracker.DefaultPreservationRules() {
+		if r.Path != "/contacts/[_role=COURIER]" {
+			rules = append(rules, r)
+		}
+	}
+	merged, _ := tracker.MergeOrderData(prev, next, true, tracker.WithMergeRules(rules))
+	oc := tracker.ExtractContacts(merged)
+	if oc.Courier != nil {
+		t.Errorf("Courier = %+v, want nil without its rule", oc.Courier)
+	}
+	if oc.Restaurant == nil || oc.Restaurant.Phone != "+33 1 42 00 00 00" {
+		t.Errorf("Restaurant = %+v", oc.Restaurant)
+	}
+}
+
+func TestPreservationRule_Validate(t *testing.T) {
+	valid := []string{
+		"/contacts",
+		"/feedCards/*/courier/0/name",
+		"/backgroundFeedCards/*/mapEntity/[type=RESTAURANT]/title",
+		"/activeOrderOverview/items/[title=*]/price",
+	}
+	for _, p := range valid {
+		if err := (tracker.PreservationRule{Path: p}).Validate(); err != nil {
+			t.Errorf("Validate(%q) = %v, want nil", p, err)
+		}
+	}
+	for _, p := range []string{"", "contacts", "/contacts/[type]", "/contacts/[=x]", "/feedCards/[uuid=*]/courier/[name=*]"} {
+		if err := (tracker.PreservationRule{Path: p}).Validate(); !errors.Is(err, tracker.ErrInvalidRule) {
+			t.Errorf("Validate(%q) = %v, want ErrInvalidRule", p, err)
+		}
+	}
+}
+
+func TestManager_WithPreservationRules(t *testing.T) {
+	mgr := tracker.NewManager(testutil.NewMockOrderStore(), testutil.NewMockFetch().Fn())
+	defer mgr.Shutdown()
+
+	var logs bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(prev)
+
+	if got := mgr.WithPreservationRules(tracker.PreservationRule{Path: "invalide"}); got != mgr {
+		t.Error("WithPreservationRules should return the manager for chaining")
+	}
+	if got := strings.Count(logs.String(), "règle de préservation ignorée"); got != 1 {
+		t.Errorf("invalid rule reported %d times, want once:\n%s", got, logs.String())
+	}
+}
@@ -0,0 +1,196 @@
+// Package tracker_test — Tests Black Box pour le package tracker (limiteur de débit).
+package tracker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+// ══════════════════════════════════════════════════════════════
+// RateLimiter
+// ══════════════════════════════════════════════════════════════
+
+func TestRateLimiter_BurstThenThrottle(t *testing.T) {
+	l := tracker.NewRateLimiter(20, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("Wait #%d: %v", i, err)
+		}
+	}
+	if d := time.Since(start); d > 20*time.Millisecond {
+		t.Errorf("burst took %v, want immediate", d)
+	}
+
+	// 4 requêtes au-delà du burst à 20 req/s ≈ 200 ms
+	for i := 0; i < 4; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("Wait: %v", err)
+		}
+	}
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Errorf("6 requests took %v, want >= ~200ms", d)
+	}
+}
+
+func TestRateLimiter_SharedAcrossGoroutines(t *testing.T) {
+	l := tracker.NewRateLimiter(50, 1)
+	ctx := context.Background()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = l.Wait(ctx)
+		}()
+	}
+	wg.Wait()
+
+	// 1 immédiate + 9 à 50 req/s ≈ 180 ms
+	if d := time.Since(start); d < 140*time.Millisecond {
+		t.Errorf("10 concurrent requests took %v, want >= ~180ms", d)
+	}
+}
+
+func TestRateLimiter_WaitHonorsContext(t *testing.T) {
+	l := tracker.NewRateLimiter(0.1, 1)
+	_ = l.Wait(context.Background()) // épuise le burst
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestRateLimiter_Saturation(t *testing.T) {
+	l := tracker.NewRateLimiter(0.1, 4)
+	if s := l.Saturation(); s > 0.01 {
+		t.Errorf("fresh Saturation = %v, want 0", s)
+	}
+	for i := 0; i < 4; i++ {
+		_ = l.Wait(context.Background())
+	}
+	if s := l.Saturation(); s < 0.99 {
+		t.Errorf("Saturation after draining = %v, want ~1", s)
+	}
+}
+
+func TestRateLimiter_SessionBudget(t *testing.T) {
+	l := tracker.NewRateLimiter(1000, 100, tracker.WithSessionBudget(0.1, 1))
+	ctx := context.Background()
+
+	if err := l.WaitSession(ctx, "a"); err != nil {
+		t.Fatalf("first WaitSession: %v", err)
+	}
+	// Une autre session a son propre budget
+	if err := l.WaitSession(ctx, "b"); err != nil {
+		t.Fatalf("WaitSession(b): %v", err)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := l.WaitSession(short, "a"); err == nil {
+		t.Error("second WaitSession(a) should block past the deadline")
+	}
+}
+
+func TestRateLimiter_NoSessionBudgetIsNoop(t *testing.T) {
+	l := tracker.NewRateLimiter(0.1, 1)
+	for i := 0; i < 5; i++ {
+		if err := l.WaitSession(context.Background(), "a"); err != nil {
+			t.Fatalf("WaitSession: %v", err)
+		}
+	}
+}
+
+func TestSaturatedInterval(t *testing.T) {
+	tests := []struct {
+		name       string
+		interval   int
+		saturation float64
+		want       int
+	}{
+		{"budget libre", 30, 0, 30},
+		{"sous le seuil", 30, 0.4, 30},
+		{"épuisé", 30, 1, 60},
+		{"file d'attente", 30, 2, 90},
+		{"plafonné", 120, 3, 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tracker.SaturatedInterval(tt.interval, tt.saturation); got != tt.want {
+				t.Errorf("SaturatedInterval(%d, %v) = %d, want %d", tt.interval, tt.saturation, got, tt.want)
+			}
+		})
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Manager / SessionPool + RateLimiter
+// ══════════════════════════════════════════════════════════════
+
+func TestManager_WithRateLimiter_ThrottlesFetches(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	mockFetch := testutil.NewMockFetch()
+	for i := 0; i < 3; i++ {
+		mockFetch.QueueOrder(testutil.NewTestOrder().WithPhase("COMPLETED").Build())
+	}
+
+	limiter := tracker.NewRateLimiter(10, 1)
+	mgr := tracker.NewManager(store, mockFetch.Fn()).WithRateLimiter(limiter)
+
+	start := time.Now()
+	for _, uuid := range []string{"a", "b", "c"} {
+		mgr.StartTracking(tracker.OrderIdentity{UUID: uuid})
+	}
+	go func() {
+		for range mgr.UpdateChannel {
+		}
+	}()
+
+	deadline := time.After(3 * time.Second)
+	for mockFetch.CallCount() < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("fetch calls = %d, want 3", mockFetch.CallCount())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	mgr.Shutdown()
+
+	// 1 immédiate + 2 à 10 req/s ≈ 200 ms
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Errorf("3 fetches took %v, want >= ~200ms", d)
+	}
+}
+
+func TestSessionPool_WithSessionLimiter(t *testing.T) {
+	srv, hits := newSessionServer(t)
+	limiter := tracker.NewRateLimiter(1000, 100, tracker.WithSessionBudget(0.1, 1))
+	pool := tracker.NewSessionPool([]*tracker.Client{
+		newPoolClient(srv.URL, "a", "sid=a"),
+	}, tracker.WithSessionLimiter(limiter))
+
+	if _, err := pool.Fetch(context.Background(), "uuid-1"); err != nil {
+		t.Fatalf("first Fetch: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Fetch(ctx, "uuid-1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second Fetch err = %v, want DeadlineExceeded (session budget)", err)
+	}
+	if hits("sid=a") != 1 {
+		t.Errorf("hits = %d, want 1", hits("sid=a"))
+	}
+}
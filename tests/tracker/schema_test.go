@@ -0,0 +1,124 @@
+// Package tracker_test — Tests Black Box pour le package tracker (dérive du format).
+package tracker_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+func TestValidateSchema_Fixtures(t *testing.T) {
+	tests := []struct {
+		file    string
+		missing []string
+	}{
+		{"order_full.json", nil},
+		{"order_active.json", nil},
+		{"order_completed.json", nil}, // pas de carte une fois terminée : pas de LABEL attendu
+		{"order_no_eta.json", []string{tracker.SchemaPathETALabel}},
+		{"order_minimal.json", []string{tracker.SchemaPathStatus, tracker.SchemaPathETALabel}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			r := tracker.ValidateSchema(testutil.LoadTestJSON(t, tt.file), "")
+			if !slices.Equal(r.Missing, tt.missing) || len(r.Unknown) != 0 {
+				t.Errorf("report = %+v, want missing %v and no unknown key", r, tt.missing)
+			}
+		})
+	}
+}
+
+func TestValidateSchema_UnknownKeysAndMissingPhase(t *testing.T) {
+	raw := []byte(`{
+		"status": "success",
+		"data": {"orders": [
+			{"uuid": "other", "orderInfo": {"orderPhase": "ACTIVE"}},
+			{"uuid": "target", "feedCards": [{"status": {"title": "x"}}], "orderInfo": {}, "etaV2": {}, "riskFlags": []}
+		], "meta": {}},
+		"trace": "abc"
+	}`)
+
+	r := tracker.ValidateSchema(raw, "target")
+	wantUnknown := []string{"trace", "data.meta", "orders[].etaV2", "orders[].riskFlags"}
+	if !slices.Equal(r.Unknown, wantUnknown) {
+		t.Errorf("Unknown = %v, want %v", r.Unknown, wantUnknown)
+	}
+	wantMissing := []string{tracker.SchemaPathOrderPhase, tracker.SchemaPathETALabel}
+	if !slices.Equal(r.Missing, wantMissing) {
+		t.Errorf("Missing = %v, want %v", r.Missing, wantMissing)
+	}
+	if !r.HasDrift() || r.String() == "" {
+		t.Errorf("HasDrift/String = %v/%q", r.HasDrift(), r.String())
+	}
+}
+
+func TestSchemaMonitor_AggregatesPerHour(t *testing.T) {
+	m := tracker.NewSchemaMonitor()
+	h0 := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	drift := tracker.SchemaReport{Missing: []string{tracker.SchemaPathETALabel}, Unknown: []string{"orders[].x"}}
+
+	m.Record(tracker.SchemaReport{}, h0.Add(5*time.Minute))
+	m.Record(drift, h0.Add(50*time.Minute))
+	m.Record(drift, h0.Add(70*time.Minute))
+
+	stats := m.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("len(stats) = %d, want 2 hours", len(stats))
+	}
+	first := stats[0]
+	if !first.Hour.Equal(h0) || first.Responses != 2 || first.Drifted != 1 ||
+		first.Missing[tracker.SchemaPathETALabel] != 1 || first.Unknown["orders[].x"] != 1 {
+		t.Errorf("first hour = %+v", first)
+	}
+	if stats[1].Responses != 1 || stats[1].Drifted != 1 {
+		t.Errorf("second hour = %+v", stats[1])
+	}
+
+	// Stats retourne une copie.
+	stats[0].Missing[tracker.SchemaPathETALabel] = 99
+	if m.Stats()[0].Missing[tracker.SchemaPathETALabel] != 1 {
+		t.Error("Stats must return a copy")
+	}
+
+	// Seules les 24 dernières heures sont conservées.
+	for i := range 30 {
+		m.Record(tracker.SchemaReport{}, h0.Add(time.Duration(i+2)*time.Hour))
+	}
+	if stats := m.Stats(); len(stats) != 24 || !stats[23].Hour.Equal(h0.Add(31*time.Hour)) {
+		t.Errorf("retention: %d hours, last %v", len(stats), stats[len(stats)-1].Hour)
+	}
+}
+
+func TestManager_EmitsSchemaDrift(t *testing.T) {
+	mockFetch := testutil.NewMockFetch()
+	noLabel := testutil.LoadTestJSON(t, "order_no_eta.json")
+	for range 3 {
+		mockFetch.QueueResponse(noLabel, nil)
+	}
+
+	mon := tracker.NewSchemaMonitor()
+	mgr := tracker.NewManager(testutil.NewMockOrderStore(), mockFetch.Fn()).WithSchemaMonitor(mon)
+	mgr.StartTracking(tracker.OrderIdentity{UUID: "uuid-drift", ChannelID: "ch-1", GuildID: "g1"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	select {
+	case u := <-mgr.UpdateChannel:
+		e, ok := eventsByKind(u.Events)[tracker.EventSchemaDrift]
+		if !ok || e.After == "" {
+			t.Errorf("Events = %+v, want a SCHEMA_DRIFT", u.Events)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected an update")
+	}
+	mgr.Shutdown()
+
+	stats := mon.Stats()
+	if len(stats) == 0 || stats[len(stats)-1].Missing[tracker.SchemaPathETALabel] == 0 {
+		t.Errorf("monitor stats = %+v, want the missing LABEL recorded", stats)
+	}
+}
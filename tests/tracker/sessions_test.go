@@ -0,0 +1,156 @@
+// Package tracker_test — Tests Black Box pour le package tracker (pool de sessions).
+package tracker_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+// newSessionServer accepte toutes les requêtes sauf celles portant "sid=burned".
+// Il compte les requêtes reçues par cookie.
+func newSessionServer(t *testing.T) (*httptest.Server, func(cookie string) int) {
+	t.Helper()
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie := r.Header.Get("Cookie")
+		mu.Lock()
+		hits[cookie]++
+		mu.Unlock()
+		if strings.Contains(cookie, "sid=burned") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"orders":[]}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func(cookie string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return hits[cookie]
+	}
+}
+
+func newPoolClient(baseURL, name, cookie string, refreshes ...string) *tracker.Client {
+	return tracker.NewClient(
+		tracker.WithName(name),
+		tracker.WithBaseURL(baseURL),
+		tracker.WithCookieProvider(testutil.NewMockCookieProvider(cookie, refreshes...)),
+	)
+}
+
+// ══════════════════════════════════════════════════════════════
+// SessionPool — sélection
+// ══════════════════════════════════════════════════════════════
+
+func TestSessionPool_RoundRobin(t *testing.T) {
+	srv, hits := newSessionServer(t)
+	pool := tracker.NewSessionPool([]*tracker.Client{
+		newPoolClient(srv.URL, "a", "sid=a"),
+		newPoolClient(srv.URL, "b", "sid=b"),
+	})
+
+	for i := 0; i < 4; i++ {
+		if _, err := pool.Fetch(context.Background(), "uuid-1"); err != nil {
+			t.Fatalf("Fetch #%d: %v", i, err)
+		}
+	}
+	if hits("sid=a") != 2 || hits("sid=b") != 2 {
+		t.Errorf("hits a=%d b=%d, want 2/2", hits("sid=a"), hits("sid=b"))
+	}
+}
+
+func TestSessionPool_FailsOverOnRejectedSession(t *testing.T) {
+	srv, hits := newSessionServer(t)
+	pool := tracker.NewSessionPool([]*tracker.Client{
+		newPoolClient(srv.URL, "burned", "sid=burned", "sid=burned"),
+		newPoolClient(srv.URL, "good", "sid=good"),
+	})
+
+	if _, err := pool.Fetch(context.Background(), "uuid-1"); err != nil {
+		t.Fatalf("Fetch should fail over to the good session: %v", err)
+	}
+	if hits("sid=good") != 1 {
+		t.Errorf("good session hits = %d, want 1", hits("sid=good"))
+	}
+
+	stats := pool.Stats()
+	if stats[0].Name != "burned" || stats[0].ConsecutiveFailures != 1 {
+		t.Errorf("burned stats = %+v, want 1 consecutive failure", stats[0])
+	}
+	if stats[1].Failures != 0 || stats[1].Requests != 1 {
+		t.Errorf("good stats = %+v, want 1 request and no failure", stats[1])
+	}
+}
+
+func TestSessionPool_QuarantinesBurnedSession(t *testing.T) {
+	srv, _ := newSessionServer(t)
+	pool := tracker.NewSessionPool([]*tracker.Client{
+		newPoolClient(srv.URL, "burned", "sid=burned", "sid=burned", "sid=burned"),
+		newPoolClient(srv.URL, "good", "sid=good"),
+	}, tracker.WithQuarantine(2, time.Hour))
+
+	for i := 0; i < 4; i++ {
+		if _, err := pool.Fetch(context.Background(), "uuid-1"); err != nil {
+			t.Fatalf("Fetch #%d: %v", i, err)
+		}
+	}
+
+	burned := pool.Stats()[0]
+	if !burned.Quarantined(time.Now()) {
+		t.Fatalf("burned session should be quarantined, stats = %+v", burned)
+	}
+	if burned.Requests != 2 {
+		t.Errorf("burned requests = %d, want 2 (no traffic once quarantined)", burned.Requests)
+	}
+}
+
+func TestSessionPool_AllQuarantined(t *testing.T) {
+	srv, _ := newSessionServer(t)
+	pool := tracker.NewSessionPool([]*tracker.Client{
+		newPoolClient(srv.URL, "burned", "sid=burned", "sid=burned"),
+	}, tracker.WithQuarantine(1, time.Hour))
+
+	if _, err := pool.Fetch(context.Background(), "uuid-1"); err == nil {
+		t.Fatal("first Fetch should fail")
+	}
+	if _, err := pool.Fetch(context.Background(), "uuid-1"); err == nil {
+		t.Fatal("Fetch should fail when every session is quarantined")
+	}
+	if got := pool.Stats()[0].Requests; got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestSessionPool_LeastRecentlyFailed(t *testing.T) {
+	srv, hits := newSessionServer(t)
+	pool := tracker.NewSessionPool([]*tracker.Client{
+		newPoolClient(srv.URL, "burned", "sid=burned", "sid=burned"),
+		newPoolClient(srv.URL, "good", "sid=good"),
+	}, tracker.WithSelectionStrategy(tracker.LeastRecentlyFailed), tracker.WithQuarantine(0, 0))
+
+	for i := 0; i < 3; i++ {
+		if _, err := pool.Fetch(context.Background(), "uuid-1"); err != nil {
+			t.Fatalf("Fetch #%d: %v", i, err)
+		}
+	}
+	// Après son premier échec, la session "burned" n'est plus choisie en premier.
+	if hits("sid=good") != 3 {
+		t.Errorf("good session hits = %d, want 3", hits("sid=good"))
+	}
+}
+
+func TestSessionPool_WorksAsManagerFetchFn(t *testing.T) {
+	pool := tracker.NewSessionPool(nil)
+	if mgr := tracker.NewManager(testutil.NewMockOrderStore(), pool.Fetch); mgr == nil {
+		t.Fatal("NewManager returned nil")
+	}
+}
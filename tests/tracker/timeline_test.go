@@ -0,0 +1,161 @@
+// Package tracker_test — Tests Black Box pour le package tracker (chronologie).
+package tracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+)
+
+func TestTimeline_DeliveryCassette(t *testing.T) {
+	ctx := context.Background()
+	store := testutil.NewMockOrderStore()
+
+	var timeline tracker.Timeline
+	for _, it := range testutil.LoadTestCassette(t, "cassette_delivery.jsonl") {
+		if it.Status != 200 {
+			continue
+		}
+		var resp tracker.Response
+		if err := json.Unmarshal(it.Body, &resp); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		r, err := tracker.Reconcile(ctx, store, cassetteUUID, resp)
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		if err := store.SaveOrder(ctx, tracker.TrackedOrder{
+			UUID: cassetteUUID, LastStatus: string(r.Phase), LastProgress: r.Progress,
+			LastText: r.Text, FullJSONData: r.FinalJSON,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		timeline, _ = timeline.Observe(r, it.Time)
+	}
+
+	start := time.Date(2025, 3, 14, 19, 40, 0, 0, time.UTC)
+	want := map[tracker.Milestone]time.Time{
+		tracker.MilestonePlaced:          start,
+		tracker.MilestoneAccepted:        start,
+		tracker.MilestonePreparing:       start.Add(30 * time.Second),
+		tracker.MilestoneCourierAssigned: start.Add(2 * time.Minute),
+		tracker.MilestonePickedUp:        start.Add(2 * time.Minute),
+		tracker.MilestoneDelivered:       start.Add(3*time.Minute + 30*time.Second),
+	}
+	for m, at := range want {
+		if got, ok := timeline.At(m); !ok || !got.Equal(at) {
+			t.Errorf("%s at %v (%v), want %v", m, got, ok, at)
+		}
+	}
+	if _, ok := timeline.At(tracker.MilestoneArriving); ok {
+		t.Error("ARRIVING should not be observed (ETA never ≤ 2 min)")
+	}
+	for i := 1; i < len(timeline); i++ {
+		if timeline[i].At.Before(timeline[i-1].At) {
+			t.Errorf("timeline not chronological: %+v", timeline)
+		}
+	}
+
+	if d, ok := timeline.PreparationTime(); !ok || d != 2*time.Minute {
+		t.Errorf("PreparationTime = %v (%v), want 2m", d, ok)
+	}
+	if d, ok := timeline.DeliveryTime(); !ok || d != 90*time.Second {
+		t.Errorf("DeliveryTime = %v (%v), want 1m30s", d, ok)
+	}
+	if d, ok := timeline.TotalTime(); !ok || d != 210*time.Second {
+		t.Errorf("TotalTime = %v (%v), want 3m30s", d, ok)
+	}
+}
+
+func TestTimeline_ObserveKeepsFirstSeen(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	r := tracker.ReconcileResult{Phase: tracker.PhaseActive, Progress: 2, TotalProgress: 5, Eta: 20}
+
+	tl, changed := tracker.Timeline(nil).Observe(r, t0)
+	if !changed || len(tl) != 3 {
+		t.Fatalf("timeline = %+v, want PLACED, ACCEPTED, PREPARING", tl)
+	}
+	if _, changed := tl.Observe(r, t0.Add(time.Minute)); changed {
+		t.Error("same poll must not change the timeline")
+	}
+
+	// Livreur en route, bientôt arrivé.
+	r.Phase, r.Progress, r.Eta = tracker.PhaseEnRoute, 4, 1
+	tl, _ = tl.Observe(r, t0.Add(10*time.Minute))
+	if at, ok := tl.At(tracker.MilestoneArriving); !ok || !at.Equal(t0.Add(10*time.Minute)) {
+		t.Errorf("ARRIVING = %v (%v)", at, ok)
+	}
+	if at, _ := tl.At(tracker.MilestonePreparing); !at.Equal(t0) {
+		t.Errorf("PREPARING moved to %v, want first-seen %v", at, t0)
+	}
+
+	// Une commande annulée ne franchit plus de jalon.
+	cancelled := tracker.ReconcileResult{Phase: tracker.PhaseCancelled, Progress: 5, TotalProgress: 5}
+	if _, changed := tl.Observe(cancelled, t0.Add(20*time.Minute)); changed {
+		t.Error("a cancelled order must not reach DELIVERED")
+	}
+}
+
+func TestTimeline_SkippedMilestonesShareObservation(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	tl, _ := tracker.Timeline(nil).Observe(tracker.ReconcileResult{Phase: tracker.PhaseActive, Progress: 1, TotalProgress: 5, Eta: 30}, t0)
+
+	// Le poll suivant trouve la commande livrée : préparation, livreur et
+	// enlèvement ont été franchis sans être observés.
+	t1 := t0.Add(25 * time.Minute)
+	tl, _ = tl.Observe(tracker.ReconcileResult{Phase: tracker.PhaseCompleted, Progress: 5, TotalProgress: 5, Eta: -1}, t1)
+
+	for _, m := range []tracker.Milestone{
+		tracker.MilestonePreparing, tracker.MilestoneCourierAssigned, tracker.MilestonePickedUp, tracker.MilestoneDelivered,
+	} {
+		if at, ok := tl.At(m); !ok || !at.Equal(t1) {
+			t.Errorf("%s = %v (%v), want the revealing poll %v", m, at, ok, t1)
+		}
+	}
+	if at, _ := tl.At(tracker.MilestoneAccepted); !at.Equal(t0) {
+		t.Errorf("ACCEPTED = %v, want %v", at, t0)
+	}
+	if _, ok := tl.At(tracker.MilestoneArriving); ok {
+		t.Error("ARRIVING is never implied by a delivery")
+	}
+	if d, ok := tl.DeliveryTime(); !ok || d != 0 {
+		t.Errorf("DeliveryTime = %v (%v), want 0 when pickup was not observed", d, ok)
+	}
+}
+
+func TestWorker_TimelineStoredAndResumed(t *testing.T) {
+	store := testutil.NewMockOrderStore()
+	placedAt := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	if err := store.SaveTimeline(context.Background(), "uuid-full", tracker.Timeline{
+		{Milestone: tracker.MilestonePlaced, At: placedAt},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	mockFetch := testutil.NewMockFetch()
+	mockFetch.QueueResponse(testutil.LoadTestJSON(t, "order_full.json"), nil)
+	mgr := tracker.NewManager(store, mockFetch.Fn())
+	defer mgr.Shutdown()
+	mgr.StartTracking(tracker.OrderIdentity{UUID: "uuid-full", ChannelID: "ch-1", GuildID: "g1"})
+
+	select {
+	case u := <-mgr.UpdateChannel:
+		if at, ok := u.Timeline.At(tracker.MilestonePlaced); !ok || !at.Equal(placedAt) {
+			t.Errorf("PLACED = %v (%v), want resumed %v", at, ok, placedAt)
+		}
+		if _, ok := u.Timeline.At(tracker.MilestonePickedUp); !ok {
+			t.Errorf("Timeline = %+v, want PICKED_UP (order en route)", u.Timeline)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected an update")
+	}
+
+	stored, _ := store.GetTimeline(context.Background(), "uuid-full")
+	if len(stored) < 2 {
+		t.Errorf("stored timeline = %+v, want the new milestones persisted", stored)
+	}
+}
@@ -0,0 +1,211 @@
+// Package tracker_test — Tests d'intégration du chemin réseau réel contre trackertest.Server.
+package tracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/superselle/ubertracker/tests/testutil"
+	"github.com/superselle/ubertracker/tracker"
+	"github.com/superselle/ubertracker/tracker/trackertest"
+)
+
+func newFakeUber(t *testing.T) *trackertest.Server {
+	t.Helper()
+	srv := trackertest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func phaseOf(t *testing.T, data []byte) string {
+	t.Helper()
+	var resp tracker.Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(resp.Data.Orders) == 0 {
+		return ""
+	}
+	return resp.Data.Orders[0].OrderStatus.OrderPhase
+}
+
+// ══════════════════════════════════════════════════════════════
+// FetchUberJSON / Client de bout en bout
+// ══════════════════════════════════════════════════════════════
+
+func TestFetchUberJSON_AgainstFakeServer(t *testing.T) {
+	srv := newFakeUber(t)
+	srv.AddOrder("uuid-1", trackertest.Phases("ACTIVE", "COMPLETED")...)
+
+	prev := tracker.SetDefaultClient(srv.Client(tracker.WithCookies("sid=abc")))
+	t.Cleanup(func() { tracker.SetDefaultClient(prev) })
+
+	ctx := context.Background()
+	for _, want := range []string{"ACTIVE", "COMPLETED", "COMPLETED"} {
+		data, err := tracker.FetchUberJSON(ctx, "uuid-1")
+		if err != nil {
+			t.Fatalf("FetchUberJSON: %v", err)
+		}
+		if got := phaseOf(t, data); got != want {
+			t.Errorf("phase = %q, want %q", got, want)
+		}
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("requests = %d, want 3", len(reqs))
+	}
+	if r := reqs[0]; r.Status != http.StatusOK || r.Cookie != "sid=abc" || r.Payload.Timezone != "Europe/Paris" || r.Locale != "fr" {
+		t.Errorf("first request = %+v", r)
+	}
+}
+
+func TestClient_UnknownOrderIsEmptyList(t *testing.T) {
+	srv := newFakeUber(t)
+	data, err := srv.Client().Fetch(context.Background(), "nope")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if got := phaseOf(t, data); got != "" {
+		t.Errorf("phase = %q, want no order", got)
+	}
+}
+
+func TestClient_RefreshPathAgainstFakeServer(t *testing.T) {
+	srv := newFakeUber(t)
+	srv.AddOrder("uuid-1", trackertest.Phases("ACTIVE")...)
+	srv.RequireCookie("jwt-session", "valid")
+
+	client := srv.Client(tracker.WithCookieProvider(
+		tracker.NewMemoryCookieProvider(tracker.ParseCookieHeader("jwt-session=stale"), srv.Refresher()),
+	))
+
+	data, err := client.Fetch(context.Background(), "uuid-1")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if phaseOf(t, data) != "ACTIVE" {
+		t.Error("expected the order after cookie refresh")
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 || reqs[0].Status != http.StatusForbidden || reqs[1].Status != http.StatusOK {
+		t.Errorf("requests = %+v, want 403 then 200", reqs)
+	}
+	if reqs[1].Cookie != "jwt-session=valid" {
+		t.Errorf("retry cookie = %q, want jwt-session=valid", reqs[1].Cookie)
+	}
+}
+
+func TestClient_FaultsAgainstFakeServer(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault trackertest.Fault
+		want  error
+	}{
+		{"rate limited", trackertest.Fault{Status: http.StatusTooManyRequests, RetryAfter: "5"}, tracker.ErrRateLimited},
+		{"server", trackertest.Fault{Status: http.StatusServiceUnavailable}, tracker.ErrServer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeUber(t)
+			srv.AddOrder("uuid-1", trackertest.Phases("ACTIVE")...)
+			srv.Fail(tt.fault)
+			client := srv.Client()
+
+			if _, err := client.Fetch(context.Background(), "uuid-1"); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			// La faute est consommée : la requête suivante aboutit.
+			if _, err := client.Fetch(context.Background(), "uuid-1"); err != nil {
+				t.Errorf("second Fetch: %v", err)
+			}
+		})
+	}
+
+	t.Run("retry-after", func(t *testing.T) {
+		srv := newFakeUber(t)
+		srv.Fail(trackertest.Fault{Status: http.StatusTooManyRequests, RetryAfter: "5"})
+		_, err := srv.Client().Fetch(context.Background(), "uuid-1")
+		if d, ok := tracker.RetryAfter(err); !ok || d != 5*time.Second {
+			t.Errorf("RetryAfter = (%v, %v), want (5s, true)", d, ok)
+		}
+	})
+}
+
+func TestClient_PersistentUnauthorizedAgainstFakeServer(t *testing.T) {
+	srv := newFakeUber(t)
+	srv.FailNext(http.StatusUnauthorized, 2)
+
+	client := srv.Client(tracker.WithCookieProvider(
+		tracker.NewMemoryCookieProvider(tracker.ParseCookieHeader("sid=x"), srv.Refresher()),
+	))
+	if _, err := client.Fetch(context.Background(), "uuid-1"); !errors.Is(err, tracker.ErrUnauthorized) {
+		t.Errorf("err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestClient_SetCookieFromFakeServer(t *testing.T) {
+	srv := newFakeUber(t)
+	srv.SetCookieOnSuccess("jwt-session=rotated; Path=/; Max-Age=3600")
+
+	jar := tracker.ParseCookieHeader("jwt-session=initial")
+	client := srv.Client(tracker.WithCookieProvider(tracker.NewMemoryCookieProvider(jar)))
+	if _, err := client.Fetch(context.Background(), "uuid-1"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if got := jar.Header(); got != "jwt-session=rotated" {
+		t.Errorf("jar = %q, want rotated cookie", got)
+	}
+}
+
+func TestFakeServer_RejectsMalformedRequests(t *testing.T) {
+	srv := newFakeUber(t)
+
+	resp, err := http.Get(srv.URL + trackertest.ActiveOrdersPath + "?localeCode=fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET status = %d, want 400", resp.StatusCode)
+	}
+
+	// Le client réel envoie toujours une requête valide.
+	if _, err := srv.Client(tracker.WithLocale("en")).Fetch(context.Background(), "uuid-1"); err != nil {
+		t.Errorf("valid request rejected: %v", err)
+	}
+	if reqs := srv.Requests(); reqs[len(reqs)-1].Status != http.StatusOK {
+		t.Errorf("last status = %d, want 200", reqs[len(reqs)-1].Status)
+	}
+}
+
+// ══════════════════════════════════════════════════════════════
+// Manager de bout en bout
+// ══════════════════════════════════════════════════════════════
+
+func TestManager_EndToEndAgainstFakeServer(t *testing.T) {
+	srv := newFakeUber(t)
+	srv.AddOrder("uuid-1", trackertest.Phases("COMPLETED")...)
+
+	mgr := tracker.NewManager(testutil.NewMockOrderStore(), srv.Client().Fetch)
+	mgr.StartTracking(tracker.OrderIdentity{UUID: "uuid-1", ChannelID: "ch-1"})
+
+	select {
+	case u := <-mgr.UpdateChannel:
+		if u.UUID != "uuid-1" || u.LastStatus != "COMPLETED" {
+			t.Errorf("update = %s/%s, want uuid-1/COMPLETED", u.UUID, u.LastStatus)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update received")
+	}
+	mgr.Shutdown()
+
+	if srv.Hits("uuid-1") != 1 {
+		t.Errorf("hits = %d, want 1", srv.Hits("uuid-1"))
+	}
+}
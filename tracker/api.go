@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/sync/singleflight"
 
@@ -16,20 +19,6 @@ import (
 	"github.com/bogdanfinn/tls-client/profiles"
 )
 
-// GlobalCookies et CookieMutex stockent les cookies partagés entre tous les workers.
-var (
-	GlobalCookies string
-	CookieMutex   sync.RWMutex
-
-	// cookieRefreshGroup déduplique les appels concurrents de renouvellement de cookies.
-	cookieRefreshGroup singleflight.Group
-
-	// Client TLS réutilisable (un seul client pour toutes les requêtes).
-	sharedTLSClient     tls_client.HttpClient
-	sharedTLSClientOnce sync.Once
-	sharedTLSClientErr  error
-)
-
 // TrackingPayload : La structure requise par l'API Uber
 type TrackingPayload struct {
 	OrderUUID                 string `json:"orderUuid"`
@@ -38,113 +27,624 @@ type TrackingPayload struct {
 	IsDirectTracking          bool   `json:"isDirectTracking"`
 }
 
-// FetchUberJSON tente de récupérer le JSON de la commande.
-func FetchUberJSON(ctx context.Context, orderUUID string) ([]byte, error) {
+// ==========================================
+// Client configurable
+// ==========================================
+
+// Client interroge l'endpoint getActiveOrdersV1 avec sa propre configuration
+// (URL, locale, fuseau, empreinte TLS) et ses propres cookies. Plusieurs
+// clients peuvent coexister dans le même processus.
+type Client struct {
+	name      string
+	baseURL   string
+	locale    string
+	pack      *LocalePack // nil = pack déduit de locale
+	timezone  string
+	userAgent string
+	profile   profiles.ClientProfile
+	timeout   time.Duration
+	headers   map[string]string
+
+	initialCookies  string
+	cookies         CookieProvider
+	criticalCookies []string
+	refreshMargin   time.Duration
+	browserPool     *BrowserPool
+
+	// refreshGroup déduplique les appels concurrents de renouvellement de
+	// cookies ; refreshing est le renouvellement partagé en vol (voir refreshCookies).
+	refreshGroup singleflight.Group
+	refreshMu    sync.Mutex
+	refreshing   *sharedRefresh
+
+	// Proxy fixe (WithProxy) ou pool de proxies en rotation (WithProxyPool).
+	proxy        string
+	proxyPool    *ProxyPool
+	proxyMu      sync.Mutex
+	currentProxy string
+
+	// Clients TLS réutilisables, un par proxy, créés paresseusement au premier appel.
+	httpClientsMu sync.Mutex
+	httpClients   map[string]*tlsClientEntry
+}
+
+// tlsClientEntry mémorise un client TLS ou l'erreur rencontrée à sa création.
+type tlsClientEntry struct {
+	client tls_client.HttpClient
+	err    error
+}
+
+// ClientOption configure un Client lors de sa création.
+type ClientOption func(*Client)
+
+// WithName nomme le client (identifiant de session dans les logs et les stats).
+func WithName(name string) ClientOption {
+	return func(c *Client) { c.name = name }
+}
+
+// WithBaseURL remplace l'URL de base (ex : un serveur local pour les tests).
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) { c.baseURL = strings.TrimRight(baseURL, "/") }
+}
+
+// WithLocale définit le paramètre localeCode envoyé à l'API.
+func WithLocale(locale string) ClientOption {
+	return func(c *Client) { c.locale = locale }
+}
+
+// WithLocalePack impose le pack de langue du client, au lieu de celui déduit
+// de son localeCode (voir LocalePackFor).
+func WithLocalePack(p LocalePack) ClientOption {
+	return func(c *Client) { c.pack = &p }
+}
+
+// WithTimezone définit le fuseau horaire envoyé dans le TrackingPayload.
+func WithTimezone(tz string) ClientOption {
+	return func(c *Client) { c.timezone = tz }
+}
+
+// WithTLSProfile choisit l'empreinte TLS imitée par le client.
+func WithTLSProfile(profile profiles.ClientProfile) ClientOption {
+	return func(c *Client) { c.profile = profile }
+}
+
+// WithTimeout définit le timeout global d'une requête.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) { c.timeout = d }
+}
+
+// WithUserAgent remplace le User-Agent (utilisé aussi par le navigateur de secours).
+func WithUserAgent(ua string) ClientOption {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// WithHeader ajoute ou remplace un header envoyé à chaque requête.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) { c.headers[key] = value }
+}
+
+// WithCookies initialise les cookies du client (format "nom=valeur; nom2=valeur2").
+// Ils sont renouvelés via le navigateur headless quand l'API les refuse.
+func WithCookies(cookies string) ClientOption {
+	return func(c *Client) { c.initialCookies = cookies }
+}
+
+// WithCriticalCookies désigne les cookies dont l'expiration déclenche un
+// renouvellement proactif (aucun nom = tous les cookies ayant une expiration).
+func WithCriticalCookies(names ...string) ClientOption {
+	return func(c *Client) { c.criticalCookies = names }
+}
+
+// WithRefreshMargin définit l'avance avec laquelle les cookies critiques sont
+// renouvelés avant leur expiration. 0 désactive le renouvellement proactif.
+func WithRefreshMargin(d time.Duration) ClientOption {
+	return func(c *Client) { c.refreshMargin = d }
+}
+
+// WithCookieProvider remplace la source des cookies (mémoire, fichier, navigateur…).
+func WithCookieProvider(p CookieProvider) ClientOption {
+	return func(c *Client) { c.cookies = p }
+}
+
+// WithProxy fait passer toutes les requêtes du client (et le navigateur de
+// renouvellement des cookies) par un proxy HTTP(S) ou SOCKS5.
+func WithProxy(proxyURL string) ClientOption {
+	return func(c *Client) { c.proxy = proxyURL }
+}
+
+// WithProxyPool fait tourner le client sur les proxies du pool : le même proxy
+// est conservé tant qu'il est sain, puis remplacé quand il est écarté.
+func WithProxyPool(p *ProxyPool) ClientOption {
+	return func(c *Client) { c.proxyPool = p }
+}
+
+// WithBrowserPool utilise pool pour renouveler les cookies via le navigateur
+// (sans effet avec WithCookieProvider). Par défaut, un pool partagé est utilisé.
+func WithBrowserPool(pool *BrowserPool) ClientOption {
+	return func(c *Client) { c.browserPool = pool }
+}
+
+// NewClient crée un Client. Sans option, il reproduit le comportement
+// historique : ubereats.com, locale fr, Europe/Paris, Chrome 120, 30 s.
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL:   defaultBaseURL,
+		locale:    defaultLocale,
+		timezone:  defaultTimezone,
+		userAgent: defaultUserAgent,
+		profile:   profiles.Chrome_120,
+		timeout:   defaultTimeout,
+		headers:   make(map[string]string),
+
+		httpClients: make(map[string]*tlsClientEntry),
+
+		criticalCookies: defaultCriticalCookies,
+		refreshMargin:   defaultRefreshMargin,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.cookies == nil {
+		browser := NewBrowserCookieProvider(c.userAgent).UsePool(c.browserPool)
+		if c.initialCookies != "" {
+			c.cookies = NewMemoryCookieProvider(ParseCookieHeader(c.initialCookies), browser)
+		} else {
+			c.cookies = browser
+		}
+	}
+	return c
+}
+
+// Vérification compile-time : Client.Fetch satisfait FetchFn.
+var _ FetchFn = (*Client)(nil).Fetch
+
+// Vérification compile-time : Client.FetchBatch satisfait BatchFetchFn.
+var _ BatchFetchFn = (*Client)(nil).FetchBatch
+
+// defaultClient est utilisé par FetchUberJSON (voir SetDefaultClient).
+var defaultClient atomic.Pointer[Client]
+
+func init() {
+	browser := NewBrowserCookieProvider(defaultUserAgent)
+	defaultClient.Store(NewClient(WithCookieProvider(&globalCookieProvider{inner: browser})))
+}
+
+// GlobalCookies et CookieMutex stockent les cookies du client par défaut
+// (FetchUberJSON) : une valeur écrite ici est utilisée à la requête suivante,
+// et chaque renouvellement y est recopié.
+//
+// Deprecated: créer un Client avec WithCookies ou WithCookieProvider.
+var (
+	GlobalCookies string
+	CookieMutex   sync.RWMutex
+)
+
+// globalCookieProvider relie le client par défaut à GlobalCookies.
+type globalCookieProvider struct {
+	inner CookieProvider
+
+	mu   sync.Mutex
+	seen string     // dernière valeur de GlobalCookies prise en compte
+	jar  *CookieJar // jar issu de seen, nil = celui de inner
+}
+
+func (p *globalCookieProvider) Get(ctx context.Context) (*CookieJar, error) {
 	CookieMutex.RLock()
-	currentCookies := GlobalCookies
+	header := GlobalCookies
 	CookieMutex.RUnlock()
 
-	jsonBytes, statusCode, err := performRequest(ctx, orderUUID, currentCookies)
+	p.mu.Lock()
+	if header != "" && header != p.seen {
+		p.seen, p.jar = header, ParseCookieHeader(header)
+	}
+	jar := p.jar
+	p.mu.Unlock()
+	if jar != nil {
+		return jar, nil
+	}
+	return p.inner.Get(ctx)
+}
+
+func (p *globalCookieProvider) Refresh(ctx context.Context, orderURL string) (*CookieJar, error) {
+	jar, err := p.inner.Refresh(ctx, orderURL)
 	if err != nil {
 		return nil, err
 	}
+	header := jar.Header()
+	CookieMutex.Lock()
+	GlobalCookies = header
+	CookieMutex.Unlock()
 
-	if statusCode == 401 || statusCode == 403 {
-		publicURL := fmt.Sprintf("https://www.ubereats.com/orders/%s", orderUUID)
+	p.mu.Lock()
+	p.seen, p.jar = header, jar
+	p.mu.Unlock()
+	return jar, nil
+}
 
-		result, err, _ := cookieRefreshGroup.Do("refresh", func() (interface{}, error) {
-			return GetFreshCookies(publicURL)
-		})
-		if err != nil {
-			return nil, fmt.Errorf("échec du renouvellement des cookies: %w", err)
+func (p *globalCookieProvider) Invalidate() { p.inner.Invalidate() }
+
+// SetDefaultClient remplace le client utilisé par FetchUberJSON et retourne
+// le précédent (ex : pointer FetchUberJSON vers trackertest.Server).
+func SetDefaultClient(c *Client) *Client {
+	return defaultClient.Swap(c)
+}
+
+// FetchUberJSON tente de récupérer le JSON de la commande avec le client par défaut.
+func FetchUberJSON(ctx context.Context, orderUUID string) ([]byte, error) {
+	return defaultClient.Load().Fetch(ctx, orderUUID)
+}
+
+// Name retourne le nom du client (voir WithName).
+func (c *Client) Name() string {
+	return c.name
+}
+
+// LocalePack retourne le pack de langue à utiliser pour interpréter les
+// réponses de ce client. Les workers d'un Manager l'appliquent d'eux-mêmes
+// aux réponses obtenues par ce client (voir Manager.WithLocalePack).
+func (c *Client) LocalePack() LocalePack {
+	if c.pack != nil {
+		return *c.pack
+	}
+	return LocalePackFor(c.locale)
+}
+
+// CookieProvider retourne la source de cookies utilisée par le client.
+func (c *Client) CookieProvider() CookieProvider {
+	return c.cookies
+}
+
+// Fetch tente de récupérer le JSON de la commande. Les cookies sont renouvelés
+// via le CookieProvider avant l'expiration d'un cookie critique, ou après un
+// 401/403 (la requête est alors rejouée). Les Set-Cookie reçus sont intégrés au jar.
+// Les erreurs sont typées (ErrUnauthorized, ErrRateLimited, ErrServer, ErrNetwork…).
+// Avec un proxy, le renouvellement des cookies passe par le même proxy.
+func (c *Client) Fetch(ctx context.Context, orderUUID string) ([]byte, error) {
+	reportLocale(ctx, c.LocalePack())
+	proxy, err := c.activeProxy()
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.fetch(contextWithProxy(ctx, proxy), proxy, orderUUID)
+	if c.proxyPool != nil {
+		c.proxyPool.Report(proxy, err)
+	}
+	return data, err
+}
+
+// fetch est l'implémentation de Fetch pour un proxy donné.
+func (c *Client) fetch(ctx context.Context, proxy, orderUUID string) ([]byte, error) {
+	jar, err := c.cookies.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lecture des cookies: %w", err)
+	}
+
+	if c.expiresSoon(jar) {
+		slog.Info("cookie critique proche de l'expiration, renouvellement proactif")
+		if fresh, err := c.refreshCookies(ctx, orderUUID); err != nil {
+			slog.Warn("renouvellement proactif échoué, poursuite avec les cookies actuels", "error", err)
+		} else {
+			jar = fresh
 		}
+	}
+
+	resp, err := c.performRequest(ctx, proxy, orderUUID, jar.Header())
+	if err != nil {
+		return nil, err
+	}
+	c.absorbSetCookies(jar, resp)
 
-		CookieMutex.Lock()
-		if val, ok := result.(string); ok {
-			GlobalCookies = val
+	if resp.StatusCode == 401 || resp.StatusCode == 403 {
+		c.cookies.Invalidate()
+
+		jar, err = c.refreshCookies(ctx, orderUUID)
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: échec du renouvellement des cookies: %w", ErrUnauthorized, err)
 		}
-		CookieMutex.Unlock()
 
 		slog.Info("cookies mis à jour, nouvelle tentative")
 
-		jsonBytes, statusCode, err = performRequest(ctx, orderUUID, GlobalCookies)
+		resp, err = c.performRequest(ctx, proxy, orderUUID, jar.Header())
 		if err != nil {
 			return nil, err
 		}
-		if statusCode != 200 {
-			return nil, fmt.Errorf("erreur fatale après renouvellement (Code %d)", statusCode)
+		c.absorbSetCookies(jar, resp)
+		if resp.StatusCode != 200 {
+			return nil, fmt.Errorf("après renouvellement des cookies: %w", resp.err())
 		}
-	} else if statusCode != 200 {
-		return nil, fmt.Errorf("erreur API HTTP: %d", statusCode)
+	} else if resp.StatusCode != 200 {
+		return nil, resp.err()
+	}
+
+	return resp.Body, nil
+}
+
+// FetchBatch récupère en un appel les commandes actives de la session.
+// getActiveOrdersV1 retourne toutes les commandes actives visibles par la
+// session ; seule la première UUID est transmise dans le payload. Satisfait
+// BatchFetchFn (voir BatchPoller).
+func (c *Client) FetchBatch(ctx context.Context, orderUUIDs []string) ([]byte, error) {
+	if len(orderUUIDs) == 0 {
+		return nil, fmt.Errorf("FetchBatch: aucune commande demandée")
+	}
+	return c.Fetch(ctx, orderUUIDs[0])
+}
+
+// sharedRefresh est un renouvellement de cookies partagé par plusieurs
+// appelants. Son contexte ne dépend d'aucun appelant en particulier : il
+// n'est annulé que lorsque tous les appelants ont abandonné.
+type sharedRefresh struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+// refreshKey est la clé singleflight du renouvellement des cookies : le
+// groupe étant propre au Client, un seul renouvellement de sa session est en
+// vol à la fois.
+const refreshKey = "refresh"
+
+// refreshCookies renouvelle les cookies via le provider, en dédupliquant les
+// appels concurrents (singleflight.DoChan). L'appelant peut abandonner via ctx
+// sans interrompre les autres ; le renouvellement est annulé quand plus
+// personne ne l'attend.
+//
+// c.refreshing est non nil exactement quand refreshKey est en vol :
+// il est créé avec l'appel DoChan qui lance le renouvellement et effacé, avec
+// un Forget de la clé, sous refreshMu, quand celui-ci se termine ou est
+// abandonné. Un appelant compte donc toujours parmi les attentes de l'appel
+// qu'il rejoint.
+func (c *Client) refreshCookies(ctx context.Context, orderUUID string) (*CookieJar, error) {
+	publicURL := fmt.Sprintf("%s/orders/%s", c.baseURL, orderUUID)
+
+	c.refreshMu.Lock()
+	r := c.refreshing
+	if r == nil {
+		// Le contexte partagé conserve les valeurs de ctx (proxy du Client).
+		rctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		r = &sharedRefresh{ctx: rctx, cancel: cancel}
+		c.refreshing = r
+	}
+	r.waiters++
+	ch := c.refreshGroup.DoChan(refreshKey, func() (interface{}, error) {
+		jar, err := c.cookies.Refresh(r.ctx, publicURL)
+		c.endRefresh(r)
+		return jar, err
+	})
+	c.refreshMu.Unlock()
+
+	select {
+	case res := <-ch:
+		c.leaveRefresh(r)
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		jar, _ := res.Val.(*CookieJar)
+		if jar == nil {
+			jar = NewCookieJar()
+		}
+		return jar, nil
+	case <-ctx.Done():
+		c.leaveRefresh(r)
+		return nil, ctx.Err()
+	}
+}
+
+// endRefresh détache le renouvellement r terminé : les appelants suivants en
+// déclencheront un nouveau.
+func (c *Client) endRefresh(r *sharedRefresh) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if c.refreshing == r {
+		c.refreshing = nil
+		c.refreshGroup.Forget(refreshKey)
+	}
+}
+
+// leaveRefresh retire un appelant de r ; le dernier annule le renouvellement
+// et le détache s'il est encore en vol.
+func (c *Client) leaveRefresh(r *sharedRefresh) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	r.waiters--
+	if r.waiters > 0 {
+		return
+	}
+	r.cancel()
+	if c.refreshing == r {
+		c.refreshing = nil
+		c.refreshGroup.Forget(refreshKey)
+	}
+}
+
+// expiresSoon indique si un cookie critique du jar expire dans moins de refreshMargin.
+func (c *Client) expiresSoon(jar *CookieJar) bool {
+	if c.refreshMargin <= 0 {
+		return false
+	}
+	earliest, ok := jar.EarliestExpiry(c.criticalCookies...)
+	return ok && time.Until(earliest) < c.refreshMargin
+}
+
+// absorbSetCookies intègre les Set-Cookie d'une réponse dans le jar et le
+// persiste si le provider le permet.
+func (c *Client) absorbSetCookies(jar *CookieJar, resp rawResponse) {
+	setCookies := resp.Header.Values("Set-Cookie")
+	if len(setCookies) == 0 || jar.MergeSetCookie(setCookies) == 0 {
+		return
+	}
+	if saver, ok := c.cookies.(cookieSaver); ok {
+		if err := saver.Save(); err != nil {
+			slog.Warn("persistance des cookies échouée", "error", err)
+		}
+	}
+}
+
+// activeProxy retourne le proxy à utiliser pour la prochaine requête ("" =
+// connexion directe). Avec un pool, le proxy courant est conservé tant qu'il
+// n'est pas écarté, pour que cookies et requêtes partagent la même IP.
+func (c *Client) activeProxy() (string, error) {
+	if c.proxyPool == nil {
+		return c.proxy, nil
+	}
+	c.proxyMu.Lock()
+	defer c.proxyMu.Unlock()
+	if c.currentProxy != "" && c.proxyPool.Available(c.currentProxy) {
+		return c.currentProxy, nil
 	}
+	proxy, err := c.proxyPool.Acquire()
+	if err != nil {
+		return "", err
+	}
+	if c.currentProxy != "" {
+		slog.Info("rotation de proxy", "from", redactProxy(c.currentProxy), "to", redactProxy(proxy))
+	}
+	c.currentProxy = proxy
+	return proxy, nil
+}
 
-	return jsonBytes, nil
+// ProbeProxy vérifie que l'URL de base est joignable via proxyURL avec
+// l'empreinte TLS du client. Satisfait ProxyProbe (voir ProxyPool.CheckHealth).
+func (c *Client) ProbeProxy(ctx context.Context, proxyURL string) error {
+	client, err := c.tlsClient(proxyURL)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header = http.Header{"user-agent": {c.userAgent}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 500 {
+		return &HTTPError{StatusCode: resp.StatusCode}
+	}
+	return nil
 }
 
-// getSharedTLSClient retourne le client TLS partagé, initialisé une seule fois.
+// tlsClient retourne le client TLS associé à proxy, initialisé une seule fois.
 // En cas d'erreur lors de la création, l'erreur est mémorisée et retournée à
-// chaque appel suivant (pas de retry implicite via sync.Once).
-func getSharedTLSClient() (tls_client.HttpClient, error) {
-	sharedTLSClientOnce.Do(func() {
-		options := []tls_client.HttpClientOption{
-			tls_client.WithClientProfile(profiles.Chrome_120),
-			tls_client.WithTimeoutSeconds(30),
-			tls_client.WithRandomTLSExtensionOrder(),
+// chaque appel suivant (pas de retry implicite).
+func (c *Client) tlsClient(proxy string) (tls_client.HttpClient, error) {
+	c.httpClientsMu.Lock()
+	defer c.httpClientsMu.Unlock()
+
+	if e, ok := c.httpClients[proxy]; ok {
+		return e.client, e.err
+	}
+
+	options := []tls_client.HttpClientOption{
+		tls_client.WithClientProfile(c.profile),
+		tls_client.WithTimeoutMilliseconds(int(c.timeout / time.Millisecond)),
+		tls_client.WithRandomTLSExtensionOrder(),
+	}
+	e := &tlsClientEntry{}
+	if proxy != "" {
+		if _, err := ParseProxyURL(proxy); err != nil {
+			e.err = err
 		}
-		c, err := tls_client.NewHttpClient(tls_client.NewNoopLogger(), options...)
+		options = append(options, tls_client.WithProxyUrl(proxy))
+	}
+	if e.err == nil {
+		hc, err := tls_client.NewHttpClient(tls_client.NewNoopLogger(), options...)
 		if err != nil {
-			sharedTLSClientErr = fmt.Errorf("impossible de créer le client TLS: %w", err)
-			return
+			e.err = fmt.Errorf("impossible de créer le client TLS: %w", err)
 		}
-		sharedTLSClient = c
-	})
-	return sharedTLSClient, sharedTLSClientErr
+		e.client = hc
+	}
+	c.httpClients[proxy] = e
+	return e.client, e.err
+}
+
+// endpointURL construit l'URL de getActiveOrdersV1 avec le localeCode du client.
+func (c *Client) endpointURL() string {
+	return c.baseURL + activeOrdersPath + "?localeCode=" + url.QueryEscape(c.locale)
 }
 
-// performRequest exécute la requête HTTP pure vers l'API Uber.
-func performRequest(ctx context.Context, uuid string, cookies string) ([]byte, int, error) {
-	client, err := getSharedTLSClient()
+// requestHeaders construit les headers d'une requête. Les headers ajoutés via
+// WithHeader priment sur les valeurs par défaut.
+func (c *Client) requestHeaders(cookies string) http.Header {
+	acceptLanguage := defaultAcceptLanguage
+	if c.locale != defaultLocale {
+		acceptLanguage = c.locale + ",en;q=0.8"
+	}
+
+	authority := c.baseURL
+	if u, err := url.Parse(c.baseURL); err == nil && u.Host != "" {
+		authority = u.Host
+	}
+
+	h := http.Header{
+		"authority":       {authority},
+		"accept":          {"*/*"},
+		"accept-language": {acceptLanguage},
+		"content-type":    {"application/json"},
+		"origin":          {c.baseURL},
+		"x-csrf-token":    {"x"},
+		"Cookie":          {cookies},
+		"user-agent":      {c.userAgent},
+	}
+	for k, v := range c.headers {
+		h[k] = []string{v}
+	}
+	return h
+}
+
+// rawResponse est la réponse brute de l'endpoint, avant interprétation du statut.
+type rawResponse struct {
+	Body       []byte
+	StatusCode int
+	Header     http.Header
+}
+
+// err convertit un statut non-200 en erreur typée (voir errorForStatus).
+func (r rawResponse) err() error {
+	return errorForStatus(r.StatusCode, r.Header.Get("Retry-After"))
+}
+
+// performRequest exécute la requête HTTP pure vers l'API Uber, via proxy s'il est non vide.
+func (c *Client) performRequest(ctx context.Context, proxy, uuid, cookies string) (rawResponse, error) {
+	client, err := c.tlsClient(proxy)
 	if err != nil {
-		return nil, 0, err
+		return rawResponse{}, err
 	}
 
 	payload := TrackingPayload{
 		OrderUUID:                 uuid,
-		Timezone:                  "Europe/Paris",
+		Timezone:                  c.timezone,
 		ShowAppUpsellIllustration: true,
 		IsDirectTracking:          false,
 	}
 
 	bodyBytes, err := json.Marshal(payload)
 	if err != nil {
-		return nil, 0, fmt.Errorf("échec sérialisation payload: %w", err)
+		return rawResponse{}, fmt.Errorf("échec sérialisation payload: %w", err)
 	}
 	payloadReader := strings.NewReader(string(bodyBytes))
 
-	apiURL := "https://www.ubereats.com/_p/api/getActiveOrdersV1?localeCode=fr"
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, payloadReader)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpointURL(), payloadReader)
 	if err != nil {
-		return nil, 0, err
-	}
-
-	req.Header = http.Header{
-		"authority":       {"www.ubereats.com"},
-		"accept":          {"*/*"},
-		"accept-language": {"fr-FR,fr;q=0.9,en-US;q=0.8,en;q=0.7"},
-		"content-type":    {"application/json"},
-		"origin":          {"https://www.ubereats.com"},
-		"x-csrf-token":    {"x"},
-		"Cookie":          {cookies},
-		"user-agent":      {defaultUserAgent},
+		return rawResponse{}, err
 	}
+	req.Header = c.requestHeaders(cookies)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, 0, err
+		return rawResponse{}, fmt.Errorf("%w: %w", ErrNetwork, err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
-	return body, resp.StatusCode, err
+	if err != nil {
+		return rawResponse{}, fmt.Errorf("%w: lecture réponse: %w", ErrNetwork, err)
+	}
+	return rawResponse{Body: body, StatusCode: resp.StatusCode, Header: resp.Header}, nil
 }
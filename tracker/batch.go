@@ -0,0 +1,368 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ==========================================
+// Polling groupé (un appel, plusieurs workers)
+// ==========================================
+
+// BatchFetchFn récupère en un seul appel les commandes uuids d'une même session.
+// La réponse est au format getActiveOrdersV1 (production : Client.FetchBatch).
+type BatchFetchFn func(ctx context.Context, uuids []string) ([]byte, error)
+
+// Valeurs par défaut d'un BatchPoller.
+const (
+	// defaultBatchWindow est le temps laissé aux autres workers pour rejoindre un appel.
+	defaultBatchWindow = 250 * time.Millisecond
+	// defaultBatchTTL est la durée pendant laquelle une commande reçue sans
+	// avoir été demandée reste servie depuis le cache (inférieure au polling minimal).
+	defaultBatchTTL = 10 * time.Second
+)
+
+var errNoBatchSession = errors.New("aucune session configurée pour le polling groupé")
+
+// BatchPoller répartit les fetchs des workers entre une ou plusieurs sessions
+// et applique le limiteur par appel. BatchPoller.Fetch satisfait FetchFn.
+//
+// getActiveOrdersV1 liste toutes les commandes actives de la session : les
+// demandes arrivant dans la même fenêtre partagent un appel, dont la réponse
+// est redistribuée par UUID de commande (Order.UUID). Une commande reçue pour
+// un worker qui n'attendait pas est gardée en cache et lui est servie à son
+// prochain polling.
+type BatchPoller struct {
+	mu       sync.Mutex
+	sessions []*batchSession
+	assigned map[string]*batchSession
+	window   time.Duration
+	ttl      time.Duration
+	limiter  *RateLimiter
+}
+
+// batchSession regroupe les commandes rattachées à une même session.
+type batchSession struct {
+	fetch   BatchFetchFn
+	uuids   map[string]bool
+	cache   map[string]cachedOrder
+	pending *pendingBatch
+	locale  *LocalePack // pack du Client de la session, indiqué à son dernier appel
+}
+
+type cachedOrder struct {
+	data []byte
+	at   time.Time
+}
+
+// pendingBatch est un appel groupé en cours de constitution ou d'exécution.
+// Son contexte est annulé quand le dernier demandeur abandonne.
+type pendingBatch struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	waiting map[string]bool
+	results map[string][]byte
+	err     error
+}
+
+// BatchOption configure un BatchPoller lors de sa création.
+type BatchOption func(*BatchPoller)
+
+// WithBatchWindow définit la fenêtre de regroupement des demandes (défaut : 250 ms).
+func WithBatchWindow(d time.Duration) BatchOption {
+	return func(p *BatchPoller) { p.window = d }
+}
+
+// WithBatchTTL définit la durée de validité des commandes en cache (défaut : 10 s).
+func WithBatchTTL(d time.Duration) BatchOption {
+	return func(p *BatchPoller) { p.ttl = d }
+}
+
+// Vérification compile-time : BatchPoller.Fetch satisfait FetchFn.
+var _ FetchFn = (*BatchPoller)(nil).Fetch
+
+// NewBatchPoller crée un poller sur une ou plusieurs sessions (ex : les
+// FetchBatch de plusieurs Client). Chaque commande est rattachée durablement
+// à la session la moins chargée lors de son premier fetch.
+// Sans session, le client par défaut est utilisé.
+func NewBatchPoller(sessions []BatchFetchFn, opts ...BatchOption) *BatchPoller {
+	if len(sessions) == 0 {
+		sessions = []BatchFetchFn{defaultClient.Load().FetchBatch}
+	}
+	p := &BatchPoller{
+		assigned: make(map[string]*batchSession),
+		window:   defaultBatchWindow,
+		ttl:      defaultBatchTTL,
+	}
+	for _, fn := range sessions {
+		if fn == nil {
+			continue
+		}
+		p.sessions = append(p.sessions, &batchSession{
+			fetch: fn,
+			uuids: make(map[string]bool),
+			cache: make(map[string]cachedOrder),
+		})
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Fetch retourne la réponse de la commande uuid : depuis le cache si un appel
+// récent l'a déjà récupérée, sinon via l'appel groupé de sa session. Si la
+// réponse groupée ne la contient pas (ex : commande déjà sortie de la liste
+// des commandes actives), un appel dédié est effectué.
+func (p *BatchPoller) Fetch(ctx context.Context, uuid string) ([]byte, error) {
+	p.mu.Lock()
+	s := p.sessionFor(uuid)
+	if s == nil {
+		p.mu.Unlock()
+		return nil, errNoBatchSession
+	}
+	if c, ok := s.cache[uuid]; ok {
+		delete(s.cache, uuid)
+		if time.Since(c.at) < p.ttl {
+			p.mu.Unlock()
+			p.reportSessionLocale(ctx, s)
+			return c.data, nil
+		}
+	}
+
+	b := s.pending
+	if b == nil {
+		bctx, cancel := context.WithCancel(context.Background())
+		b = &pendingBatch{ctx: bctx, cancel: cancel, done: make(chan struct{}), waiting: make(map[string]bool)}
+		s.pending = b
+		go p.flush(s, b)
+	}
+	b.waiting[uuid] = true
+	p.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		p.leave(s, b, uuid)
+		return nil, ctx.Err()
+	}
+
+	p.reportSessionLocale(ctx, s)
+	if b.err != nil {
+		return nil, b.err
+	}
+	if data, ok := b.results[uuid]; ok {
+		return data, nil
+	}
+	data, err := p.call(ctx, s, []string{uuid})
+	if err != nil {
+		return nil, err
+	}
+	if results, err := splitResponse(data, []string{uuid}); err == nil {
+		if single, ok := results[uuid]; ok {
+			return single, nil
+		}
+	}
+	return data, nil
+}
+
+// leave retire uuid des demandeurs de b, et annule l'appel s'il n'en reste aucun.
+func (p *BatchPoller) leave(s *batchSession, b *pendingBatch, uuid string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(b.waiting, uuid)
+	if len(b.waiting) == 0 {
+		b.cancel()
+		if s.pending == b {
+			s.pending = nil
+		}
+	}
+}
+
+// Forget détache une commande dont le suivi est terminé.
+func (p *BatchPoller) Forget(uuid string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s, ok := p.assigned[uuid]; ok {
+		delete(s.uuids, uuid)
+		delete(s.cache, uuid)
+		delete(p.assigned, uuid)
+	}
+}
+
+// setLimiter fait passer chaque appel groupé par le limiteur l (voir Manager).
+func (p *BatchPoller) setLimiter(l *RateLimiter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limiter = l
+}
+
+// sessionFor retourne la session de uuid, en la rattachant à la session la
+// moins chargée si nécessaire. Appelé sous p.mu.
+func (p *BatchPoller) sessionFor(uuid string) *batchSession {
+	if s, ok := p.assigned[uuid]; ok {
+		return s
+	}
+	var chosen *batchSession
+	for _, s := range p.sessions {
+		if chosen == nil || len(s.uuids) < len(chosen.uuids) {
+			chosen = s
+		}
+	}
+	if chosen != nil {
+		chosen.uuids[uuid] = true
+		p.assigned[uuid] = chosen
+	}
+	return chosen
+}
+
+// flush attend la fin de la fenêtre, exécute l'appel groupé pour toutes les
+// commandes de la session et redistribue les résultats. L'attente et l'appel
+// s'interrompent si tous les demandeurs abandonnent.
+func (p *BatchPoller) flush(s *batchSession, b *pendingBatch) {
+	defer close(b.done)
+	defer b.cancel()
+
+	timer := time.NewTimer(p.window)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-b.ctx.Done():
+		b.err = b.ctx.Err()
+		return
+	}
+
+	p.mu.Lock()
+	if s.pending == b {
+		s.pending = nil
+	}
+	uuids := batchOrder(s.uuids, b.waiting)
+	p.mu.Unlock()
+
+	data, err := p.call(b.ctx, s, uuids)
+	if err != nil {
+		b.err = err
+		return
+	}
+
+	results, err := splitResponse(data, uuids)
+	if err != nil {
+		b.err = err
+		return
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	for uuid, d := range results {
+		if !b.waiting[uuid] && s.uuids[uuid] {
+			s.cache[uuid] = cachedOrder{data: d, at: now}
+		}
+	}
+	p.mu.Unlock()
+	b.results = results
+}
+
+// call exécute un appel groupé sur la session s, après avoir attendu le budget
+// du limiteur, et retient le pack de langue indiqué par son Client.
+func (p *BatchPoller) call(ctx context.Context, s *batchSession, uuids []string) ([]byte, error) {
+	p.mu.Lock()
+	limiter := p.limiter
+	p.mu.Unlock()
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	callCtx, reported := contextWithLocaleReport(ctx)
+	data, err := s.fetch(callCtx, uuids)
+	if reported.pack != nil {
+		p.mu.Lock()
+		s.locale = reported.pack
+		p.mu.Unlock()
+		reportLocale(ctx, *reported.pack)
+	}
+	return data, err
+}
+
+// reportSessionLocale indique au demandeur le pack de langue de la session s.
+func (p *BatchPoller) reportSessionLocale(ctx context.Context, s *batchSession) {
+	p.mu.Lock()
+	pack := s.locale
+	p.mu.Unlock()
+	if pack != nil {
+		reportLocale(ctx, *pack)
+	}
+}
+
+// batchOrder liste les commandes d'une session, celles attendues en premier
+// (la première UUID est celle transmise à l'API).
+func batchOrder(all, waiting map[string]bool) []string {
+	uuids := make([]string, 0, len(all))
+	for uuid := range all {
+		uuids = append(uuids, uuid)
+	}
+	sort.Slice(uuids, func(i, j int) bool {
+		if waiting[uuids[i]] != waiting[uuids[j]] {
+			return waiting[uuids[i]]
+		}
+		return uuids[i] < uuids[j]
+	})
+	return uuids
+}
+
+// splitResponse redistribue une réponse groupée par l'UUID de chaque
+// commande. Chaque élément de data.orders est ré-emballé seul, sous forme
+// brute, avec le reste de l'enveloppe : les clés inconnues des modèles restent
+// visibles de ValidateSchema. Une réponse à une seule commande pour un appel
+// à une seule commande est transmise telle quelle (le worker gère les cas vides).
+func splitResponse(data []byte, uuids []string) (map[string][]byte, error) {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSchema, err)
+	}
+	var envelope map[string]json.RawMessage
+	var orders []json.RawMessage
+	if raw, ok := root["data"]; ok {
+		if err := json.Unmarshal(raw, &envelope); err != nil {
+			return nil, fmt.Errorf("%w: data: %w", ErrSchema, err)
+		}
+		if raw, ok := envelope["orders"]; ok {
+			if err := json.Unmarshal(raw, &orders); err != nil {
+				return nil, fmt.Errorf("%w: data.orders: %w", ErrSchema, err)
+			}
+		}
+	}
+	if len(uuids) == 1 && len(orders) <= 1 {
+		return map[string][]byte{uuids[0]: data}, nil
+	}
+
+	wanted := make(map[string]bool, len(uuids))
+	for _, uuid := range uuids {
+		wanted[uuid] = true
+	}
+
+	results := make(map[string][]byte, len(uuids))
+	for _, order := range orders {
+		var fields map[string]json.RawMessage
+		var id string
+		if json.Unmarshal(order, &fields) != nil || json.Unmarshal(fields["uuid"], &id) != nil || !wanted[id] {
+			continue
+		}
+		var err error
+		if envelope["orders"], err = json.Marshal([]json.RawMessage{order}); err == nil {
+			if root["data"], err = json.Marshal(envelope); err == nil {
+				results[id], err = json.Marshal(root)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sérialisation commande %s: %w", SafeTruncate(id, 8), err)
+		}
+	}
+	return results, nil
+}
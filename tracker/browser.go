@@ -2,71 +2,420 @@ package tracker
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
-	"strings"
+	"path/filepath"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/emulation"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 )
 
-// GetFreshCookies lance un navigateur Chrome, navigue vers l'URL et récupère les cookies.
-// Cette fonction est lente (~5-10s) et ne doit être utilisée qu'en cas d'erreur 403/401.
+// GetFreshCookies ouvre un onglet dans le navigateur partagé, navigue vers
+// l'URL et retourne les cookies sous forme de header Cookie. Cette fonction
+// reste lente (quelques secondes) et ne doit être utilisée qu'en cas
+// d'erreur 403/401.
+//
+// Deprecated: utiliser GetFreshCookiesContext, qui retourne un CookieJar
+// (dates d'expiration comprises) et accepte un contexte.
 func GetFreshCookies(orderURL string) (string, error) {
-	slog.Info("lancement navigateur de secours pour rafraîchir les cookies")
+	jar, err := GetFreshCookiesContext(context.Background(), orderURL)
+	if err != nil {
+		return "", err
+	}
+	return jar.Header(), nil
+}
 
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.UserAgent(defaultUserAgent),
-		chromedp.Flag("headless", true), // Mettre à 'false' pour voir le navigateur (debug)
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.WindowSize(1280, 800),
-	)
+// GetFreshCookiesContext ouvre un onglet dans le navigateur partagé et
+// retourne les cookies obtenus. L'annulation de ctx ferme l'onglet et
+// interrompt le renouvellement.
+func GetFreshCookiesContext(ctx context.Context, orderURL string) (*CookieJar, error) {
+	return defaultBrowserPool().FreshCookies(ctx, orderURL, defaultUserAgent, "")
+}
 
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer cancel()
+// defaultBrowserPool est le pool partagé par GetFreshCookies et les
+// BrowserCookieProvider sans pool explicite.
+var defaultBrowserPool = sync.OnceValue(func() *BrowserPool { return NewBrowserPool() })
 
-	// Création du contexte (avec un timeout de 30s pour ne pas bloquer le bot)
-	ctx, cancel := chromedp.NewContext(allocCtx)
-	defer cancel()
-	ctx, cancel = context.WithTimeout(ctx, 40*time.Second)
+// ==========================================
+// Pool de navigateurs
+// ==========================================
+
+// Valeurs par défaut d'un BrowserPool.
+const (
+	defaultBrowserTabs    = 2
+	browserRefreshTimeout = 40 * time.Second
+	cookiePollInterval    = 250 * time.Millisecond
+)
+
+var errBrowserPoolClosed = errors.New("pool de navigateurs fermé")
+
+// BrowserStats décrit l'activité d'un BrowserPool (latence des renouvellements).
+type BrowserStats struct {
+	Refreshes    int
+	Failures     int
+	LastLatency  time.Duration
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+}
+
+// MeanLatency retourne la latence moyenne d'un renouvellement.
+func (s BrowserStats) MeanLatency() time.Duration {
+	if s.Refreshes == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Refreshes)
+}
+
+// BrowserPool garde un Chrome headless ouvert (un par proxy) et ouvre un
+// onglet isolé par renouvellement de cookies, au lieu de lancer un nouveau
+// navigateur à chaque fois. Il peut aussi piloter un Chrome déjà lancé via
+// son URL de débogage distant.
+type BrowserPool struct {
+	profileDir string
+	remoteURL  string
+	waitFor    []string
+	maxWait    time.Duration
+	tabs       chan struct{}
+
+	mu       sync.Mutex
+	browsers map[string]*pooledBrowser // par proxy
+	stats    BrowserStats
+	closed   bool
+}
+
+// pooledBrowser est un navigateur du pool. ready est fermé à la fin de son
+// démarrage ; err en porte alors l'échec éventuel.
+type pooledBrowser struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	ready  chan struct{}
+	err    error
+}
+
+// BrowserPoolOption configure un BrowserPool lors de sa création.
+type BrowserPoolOption func(*BrowserPool)
+
+// WithProfileDir réutilise un répertoire de profil Chrome (cache, stockage
+// local) d'un lancement à l'autre. Un sous-répertoire est créé par proxy.
+func WithProfileDir(dir string) BrowserPoolOption {
+	return func(p *BrowserPool) { p.profileDir = dir }
+}
+
+// WithRemoteBrowser pilote un Chrome déjà lancé avec --remote-debugging-port
+// (ex : "http://127.0.0.1:9222") au lieu d'en lancer un. Le proxy de ce
+// navigateur est celui de son lancement.
+func WithRemoteBrowser(debugURL string) BrowserPoolOption {
+	return func(p *BrowserPool) { p.remoteURL = debugURL }
+}
+
+// WithMaxTabs limite le nombre de renouvellements simultanés (défaut : 2).
+func WithMaxTabs(n int) BrowserPoolOption {
+	return func(p *BrowserPool) {
+		if n > 0 {
+			p.tabs = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithWaitForCookies définit les cookies attendus avant de rendre la main
+// (défaut : les cookies critiques, jwt-session et sid).
+func WithWaitForCookies(names ...string) BrowserPoolOption {
+	return func(p *BrowserPool) { p.waitFor = names }
+}
+
+// WithMaxCookieWait plafonne l'attente des cookies après le chargement de la page.
+func WithMaxCookieWait(d time.Duration) BrowserPoolOption {
+	return func(p *BrowserPool) { p.maxWait = d }
+}
+
+// NewBrowserPool crée un pool. Aucun navigateur n'est lancé avant le premier
+// renouvellement.
+func NewBrowserPool(opts ...BrowserPoolOption) *BrowserPool {
+	p := &BrowserPool{
+		waitFor:  defaultCriticalCookies,
+		maxWait:  time.Duration(browserSettleDelay) * time.Second,
+		tabs:     make(chan struct{}, defaultBrowserTabs),
+		browsers: make(map[string]*pooledBrowser),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// FreshCookies ouvre un onglet isolé, navigue vers orderURL avec userAgent et
+// attend que les cookies attendus soient posés (ou que l'attente maximale
+// soit écoulée). proxy sélectionne le navigateur ("" = connexion directe).
+// L'annulation de ctx ferme l'onglet ; le navigateur reste ouvert.
+func (p *BrowserPool) FreshCookies(ctx context.Context, orderURL, userAgent, proxy string) (*CookieJar, error) {
+	select {
+	case p.tabs <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-p.tabs }()
+
+	start := time.Now()
+	jar, err := p.freshCookies(ctx, orderURL, userAgent, proxy)
+	p.record(time.Since(start), err)
+	return jar, err
+}
+
+// Stats retourne une copie des statistiques du pool.
+func (p *BrowserPool) Stats() BrowserStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// Close ferme tous les navigateurs du pool. Les renouvellements suivants échouent.
+func (p *BrowserPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for key, b := range p.browsers {
+		b.cancel()
+		delete(p.browsers, key)
+	}
+}
+
+func (p *BrowserPool) freshCookies(ctx context.Context, orderURL, userAgent, proxy string) (*CookieJar, error) {
+	b, err := p.browser(ctx, proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("ouverture d'un onglet pour rafraîchir les cookies")
+	// L'onglet dépend du navigateur (chromedp), pas de l'appelant : ctx est
+	// relié à son annulation.
+	tabCtx, cancel := chromedp.NewContext(b.ctx, chromedp.WithNewBrowserContext())
 	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+	tabCtx, cancelTimeout := context.WithTimeout(tabCtx, browserRefreshTimeout)
+	defer cancelTimeout()
 
-	// Variable pour stocker les cookies bruts
 	var cookies []*network.Cookie
-
-	// Scénario de navigation
-	err := chromedp.Run(ctx,
+	err = chromedp.Run(tabCtx,
 		network.Enable(),
+		emulation.SetUserAgentOverride(userAgent),
 		chromedp.Navigate(orderURL),
 		chromedp.WaitVisible("body", chromedp.ByQuery),
-		// Laisse le temps aux scripts anti-bot d'Uber de poser leurs cookies.
-		chromedp.Sleep(time.Duration(browserSettleDelay)*time.Second),
-
-		// Extraction des cookies
+		// Attend les cookies posés par les scripts anti-bot d'Uber.
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			var err error
-			cookies, err = network.GetCookies().Do(ctx)
+			cookies, err = p.waitForCookies(ctx)
 			return err
 		}),
 	)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erreur chromedp: %w", err)
+	}
+
+	if len(cookies) == 0 {
+		return nil, fmt.Errorf("aucun cookie récupéré, Uber a peut-être bloqué l'accès")
+	}
+
+	slog.Info("cookies récupérés avec succès", "count", len(cookies))
+	return jarFromNetwork(cookies), nil
+}
+
+// waitForCookies interroge les cookies de l'onglet jusqu'à ce que tous les
+// cookies attendus soient présents, ou que maxWait soit écoulé.
+func (p *BrowserPool) waitForCookies(ctx context.Context) ([]*network.Cookie, error) {
+	deadline := time.Now().Add(p.maxWait)
+	for {
+		cookies, err := network.GetCookies().Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if hasCookies(cookies, p.waitFor) {
+			return cookies, nil
+		}
+		if time.Now().After(deadline) {
+			slog.Warn("cookies attendus absents, poursuite avec les cookies obtenus", "want", p.waitFor, "count", len(cookies))
+			return cookies, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(cookiePollInterval):
+		}
+	}
+}
+
+// browser retourne le navigateur associé à proxy, lancé au besoin. ctx
+// n'interrompt que le démarrage : le navigateur survit à l'appelant. p.mu
+// n'est pas tenu pendant le lancement : les appelants du même proxy attendent
+// le navigateur en cours de démarrage au lieu d'en lancer un second (Chrome
+// verrouille le profil).
+func (p *BrowserPool) browser(ctx context.Context, proxy string) (*pooledBrowser, error) {
+	if p.remoteURL != "" && proxy != "" {
+		slog.Warn("navigateur distant : le proxy du client est ignoré", "proxy", redactProxy(proxy))
+		proxy = ""
+	}
+
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, errBrowserPoolClosed
+		}
+		b, ok := p.browsers[proxy]
+		if !ok || b.ctx.Err() != nil {
+			b = p.newBrowser(proxy)
+			p.browsers[proxy] = b
+			p.mu.Unlock()
+			if err := p.launch(ctx, proxy, b); err != nil {
+				return nil, err
+			}
+			return b, nil
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-b.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// Un démarrage abandonné par son appelant est relancé par le suivant.
+		switch {
+		case b.err == nil:
+			return b, nil
+		case !errors.Is(b.err, context.Canceled) && !errors.Is(b.err, context.DeadlineExceeded):
+			return nil, b.err
+		}
+	}
+}
+
+// newBrowser prépare le navigateur de proxy, sans le démarrer. Appelé sous p.mu.
+func (p *BrowserPool) newBrowser(proxy string) *pooledBrowser {
+	var allocCtx context.Context
+	var cancelAlloc context.CancelFunc
+	if p.remoteURL != "" {
+		allocCtx, cancelAlloc = chromedp.NewRemoteAllocator(context.Background(), p.remoteURL)
+	} else {
+		allocCtx, cancelAlloc = chromedp.NewExecAllocator(context.Background(), p.execOptions(proxy)...)
+	}
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	// L'annulation de chromedp attend l'arrêt du navigateur et ne supporte
+	// pas d'être appelée deux fois.
+	cancel := sync.OnceFunc(func() {
+		cancelBrowser()
+		cancelAlloc()
+	})
+	return &pooledBrowser{ctx: browserCtx, cancel: cancel, ready: make(chan struct{})}
+}
+
+// launch démarre b (ou rejoint le navigateur distant) hors de p.mu, puis
+// signale les appelants en attente. Un échec retire b du pool.
+func (p *BrowserPool) launch(ctx context.Context, proxy string, b *pooledBrowser) error {
+	stop := context.AfterFunc(ctx, b.cancel)
+	err := chromedp.Run(b.ctx)
+	if !stop() || err != nil {
+		b.cancel()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = fmt.Errorf("démarrage du navigateur: %w", err)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil && p.closed {
+		b.cancel()
+		err = errBrowserPoolClosed
+	}
+	if err != nil && p.browsers[proxy] == b {
+		delete(p.browsers, proxy)
+	}
+	b.err = err
+	close(b.ready)
+	return err
+}
+
+// execOptions retourne les options de lancement d'un Chrome local.
+func (p *BrowserPool) execOptions(proxy string) []chromedp.ExecAllocatorOption {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", true), // Mettre à 'false' pour voir le navigateur (debug)
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.WindowSize(1280, 800),
+	)
+	if proxy != "" {
+		opts = append(opts, chromedp.ProxyServer(chromeProxy(proxy)))
+	}
+	if p.profileDir != "" {
+		opts = append(opts, chromedp.UserDataDir(filepath.Join(p.profileDir, profileName(proxy))))
+	}
+	return opts
+}
 
+// record met à jour les statistiques après un renouvellement.
+func (p *BrowserPool) record(latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Refreshes++
+	p.stats.LastLatency = latency
+	p.stats.TotalLatency += latency
+	p.stats.MaxLatency = max(p.stats.MaxLatency, latency)
 	if err != nil {
-		return "", fmt.Errorf("erreur chromedp: %w", err)
+		p.stats.Failures++
 	}
+	slog.Debug("renouvellement navigateur", "latency", latency, "error", err)
+}
 
-	// Formatage des cookies pour tls-client : "nom=valeur; nom2=valeur2"
-	cookieParts := make([]string, 0, len(cookies))
-	for _, c := range cookies {
-		cookieParts = append(cookieParts, fmt.Sprintf("%s=%s", c.Name, c.Value))
+// hasCookies indique si tous les cookies names sont présents.
+func hasCookies(cookies []*network.Cookie, names []string) bool {
+	if len(names) == 0 {
+		return len(cookies) > 0
 	}
-	cookieString := strings.Join(cookieParts, "; ")
+	for _, name := range names {
+		if !slices.ContainsFunc(cookies, func(c *network.Cookie) bool { return c.Name == name && c.Value != "" }) {
+			return false
+		}
+	}
+	return true
+}
 
-	if len(cookieParts) == 0 {
-		return "", fmt.Errorf("aucun cookie récupéré, Uber a peut-être bloqué l'accès")
+// profileName nomme le sous-répertoire de profil d'un proxy (Chrome verrouille
+// un profil par processus).
+func profileName(proxy string) string {
+	if proxy == "" {
+		return "direct"
 	}
+	sum := sha256.Sum256([]byte(proxy))
+	return "proxy-" + hex.EncodeToString(sum[:6])
+}
 
-	slog.Info("cookies récupérés avec succès", "count", len(cookies))
-	return cookieString, nil
+// jarFromNetwork convertit les cookies CDP en CookieJar en conservant domaine,
+// chemin et expiration.
+func jarFromNetwork(cookies []*network.Cookie) *CookieJar {
+	jar := NewCookieJar()
+	for _, c := range cookies {
+		ck := Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			HTTPOnly: c.HTTPOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Session {
+			ck.Expires = unixSeconds(c.Expires)
+		}
+		jar.Set(ck)
+	}
+	return jar
 }
@@ -0,0 +1,208 @@
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ==========================================
+// Montants et panier
+// ==========================================
+
+// ErrInvalidMoney est retournée par ParseMoney pour un montant illisible.
+var ErrInvalidMoney = errors.New("montant invalide")
+
+// Money est un montant exact en centimes (unité mineure à deux décimales).
+type Money struct {
+	Cents    int64
+	Currency string // ISO 4217, vide si inconnue
+}
+
+// currencySymbols associe les symboles rencontrés dans les réponses Uber à leur code ISO 4217.
+var currencySymbols = map[string]string{
+	"€":   "EUR",
+	"$":   "USD",
+	"US$": "USD",
+	"CA$": "CAD",
+	"£":   "GBP",
+	"CHF": "CHF",
+}
+
+// ParseMoney convertit un montant formaté ("24,90 €", "$1,234.50", "12.50 USD",
+// "-3,00 €"). Le séparateur décimal est le dernier point ou la dernière
+// virgule suivi(e) d'un ou deux chiffres ; les autres séparateurs sont des
+// séparateurs de milliers. currency (optionnel) est utilisé quand le montant
+// ne porte ni symbole ni code.
+func ParseMoney(s, currency string) (Money, error) {
+	var digits, symbol strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsDigit(r) || r == '.' || r == ',' || r == '-':
+			digits.WriteRune(r)
+		case unicode.IsSpace(r) || r == '\'':
+			// espaces (y compris insécables) et apostrophe : séparateurs de milliers
+		default:
+			symbol.WriteRune(r)
+		}
+	}
+
+	m := Money{Currency: strings.ToUpper(currency)}
+	if sym := symbol.String(); sym != "" {
+		code, ok := currencySymbols[sym]
+		if !ok {
+			code = strings.ToUpper(sym)
+			if len(code) != 3 || strings.IndexFunc(code, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0 {
+				return Money{}, fmt.Errorf("%w: devise %q inconnue dans %q", ErrInvalidMoney, sym, s)
+			}
+		}
+		m.Currency = code
+	}
+
+	raw := digits.String()
+	negative := strings.HasPrefix(raw, "-")
+	raw = strings.TrimPrefix(raw, "-")
+	if raw == "" || strings.Contains(raw, "-") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	units, fraction := raw, ""
+	if i := strings.LastIndexAny(raw, ".,"); i >= 0 && len(raw)-i-1 <= 2 {
+		units, fraction = raw[:i], raw[i+1:]
+	}
+	units = strings.NewReplacer(".", "", ",", "").Replace(units)
+	if units == "" {
+		units = "0"
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+
+	cents, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	if negative {
+		cents = -cents
+	}
+	m.Cents = cents
+	return m, nil
+}
+
+// Add retourne m + o, dans la devise de m (celle de o si m n'en a pas).
+func (m Money) Add(o Money) Money {
+	if m.Currency == "" {
+		m.Currency = o.Currency
+	}
+	m.Cents += o.Cents
+	return m
+}
+
+// Times retourne m multiplié par n.
+func (m Money) Times(n int) Money {
+	m.Cents *= int64(n)
+	return m
+}
+
+// String formate le montant, ex : "24.90 EUR" (sans devise si inconnue).
+func (m Money) String() string {
+	sign := ""
+	cents := m.Cents
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	s := fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+	if m.Currency != "" {
+		s += " " + m.Currency
+	}
+	return s
+}
+
+// CartModifier est une option d'article avec son surcoût unitaire.
+type CartModifier struct {
+	Title string
+	Price Money
+}
+
+// CartLine est un article du panier.
+type CartLine struct {
+	Title        string
+	Quantity     int
+	UnitPrice    Money // hors options
+	Modifiers    []CartModifier
+	Instructions string
+}
+
+// Total retourne (prix unitaire + options) × quantité.
+func (l CartLine) Total() Money {
+	unit := l.UnitPrice
+	for _, mod := range l.Modifiers {
+		unit = unit.Add(mod.Price)
+	}
+	return unit.Times(l.Quantity)
+}
+
+// CartFee est une ligne de frais.
+type CartFee struct {
+	Title  string
+	Amount Money
+}
+
+// Cart est le panier d'une commande, montants convertis : de quoi rendre un
+// reçu, y compris après qu'Uber a retiré ces données (voir MergeOrderData).
+type Cart struct {
+	Restaurant string
+	Lines      []CartLine
+	Subtotal   Money
+	Fees       []CartFee
+	Tip        Money
+	Total      Money
+	Currency   string
+}
+
+// ExtractCart construit le panier d'un Order. Un montant illisible est
+// journalisé et laissé à zéro, sans empêcher le reste du reçu.
+func ExtractCart(o Order) Cart {
+	summary := detailsOf(o).summary
+
+	c := Cart{Restaurant: o.ActiveOrderOverview.Title, Currency: strings.ToUpper(summary.CurrencyCode)}
+	parse := func(s string) Money {
+		if s == "" {
+			return Money{Currency: c.Currency}
+		}
+		m, err := ParseMoney(s, c.Currency)
+		if err != nil {
+			slog.Warn("montant illisible ignoré", "value", s, "error", err)
+			return Money{Currency: c.Currency}
+		}
+		return m
+	}
+
+	// La devise du total, si elle est explicite, sert aux montants sans symbole.
+	c.Total = parse(summary.Total)
+	if c.Currency == "" {
+		c.Currency = c.Total.Currency
+	}
+
+	c.Subtotal = parse(summary.Subtotal)
+	c.Tip = parse(summary.Tip)
+	for _, f := range summary.Fees {
+		c.Fees = append(c.Fees, CartFee{Title: f.Title, Amount: parse(f.Amount)})
+	}
+	for _, it := range o.ActiveOrderOverview.Items {
+		line := CartLine{
+			Title:        it.Title,
+			Quantity:     it.Quantity,
+			UnitPrice:    parse(it.Price),
+			Instructions: it.SpecialInstructions,
+		}
+		for _, mod := range it.Customizations {
+			line.Modifiers = append(line.Modifiers, CartModifier{Title: mod.Title, Price: parse(mod.Price)})
+		}
+		c.Lines = append(c.Lines, line)
+	}
+	return c
+}
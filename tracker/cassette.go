@@ -0,0 +1,226 @@
+package tracker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ==========================================
+// Enregistrement / rejeu de sessions (cassettes)
+// ==========================================
+
+// ErrCassetteExhausted est retournée par Replayer.Fetch quand la cassette ne
+// contient plus d'interaction pour la commande demandée.
+var ErrCassetteExhausted = errors.New("cassette épuisée")
+
+// Interaction est un appel enregistré dans une cassette : une ligne JSON par
+// appel (format JSON Lines), dans l'ordre chronologique.
+type Interaction struct {
+	UUID   string          `json:"uuid"`
+	Time   time.Time       `json:"time"`
+	Status int             `json:"status"`          // 200, code HTTP de l'erreur, ou 0 (erreur hors HTTP)
+	Body   json.RawMessage `json:"body,omitempty"`  // réponse JSON (status 200)
+	Error  string          `json:"error,omitempty"` // message d'erreur (status ≠ 200)
+}
+
+// Recorder enregistre chaque appel d'un FetchFn dans un fichier cassette.
+// Recorder.Fetch satisfait FetchFn.
+type Recorder struct {
+	mu    sync.Mutex
+	fetch FetchFn
+	file  *os.File
+	enc   *json.Encoder
+}
+
+// Vérification compile-time : Recorder.Fetch satisfait FetchFn.
+var _ FetchFn = (*Recorder)(nil).Fetch
+
+// NewRecorder ouvre (ou crée) la cassette path en ajout et enregistre les
+// appels de fetch. L'appelant doit appeler Close.
+func NewRecorder(path string, fetch FetchFn) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("ouverture cassette: %w", err)
+	}
+	return &Recorder{fetch: fetch, file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Fetch appelle le FetchFn enregistré et ajoute l'interaction à la cassette.
+// Un échec d'écriture est journalisé sans affecter le résultat de l'appel.
+func (r *Recorder) Fetch(ctx context.Context, uuid string) ([]byte, error) {
+	at := time.Now()
+	data, err := r.fetch(ctx, uuid)
+
+	it := Interaction{UUID: uuid, Time: at, Status: statusOf(err)}
+	switch {
+	case err != nil:
+		it.Error = err.Error()
+	case json.Valid(data):
+		it.Body = data
+	default:
+		it.Error = "réponse non JSON"
+	}
+
+	r.mu.Lock()
+	werr := r.enc.Encode(it)
+	r.mu.Unlock()
+	if werr != nil {
+		slog.Warn("écriture cassette échouée", "uuid", SafeTruncate(uuid, 8), "error", werr)
+	}
+	return data, err
+}
+
+// Close ferme la cassette.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// LoadCassette lit une cassette enregistrée par Recorder.
+func LoadCassette(path string) ([]Interaction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ouverture cassette: %w", err)
+	}
+	defer f.Close()
+
+	var out []Interaction
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var it Interaction
+		if err := json.Unmarshal(sc.Bytes(), &it); err != nil {
+			return nil, fmt.Errorf("cassette %s ligne %d: %w", path, line, err)
+		}
+		out = append(out, it)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("lecture cassette: %w", err)
+	}
+	return out, nil
+}
+
+// Replayer rejoue une cassette : chaque Fetch(uuid) retourne la prochaine
+// interaction enregistrée pour cette commande. Replayer.Fetch satisfait FetchFn.
+type Replayer struct {
+	mu      sync.Mutex
+	queues  map[string][]Interaction
+	origin  time.Time // horodatage de la première interaction
+	started time.Time // début du rejeu (premier Fetch)
+	speed   float64
+}
+
+// ReplayOption configure un Replayer lors de sa création.
+type ReplayOption func(*Replayer)
+
+// WithReplaySpeed rejoue avec le minutage d'origine divisé par factor
+// (1 = temps réel, 10 = dix fois plus vite). Par défaut, aucune attente.
+func WithReplaySpeed(factor float64) ReplayOption {
+	return func(r *Replayer) { r.speed = factor }
+}
+
+// Vérification compile-time : Replayer.Fetch satisfait FetchFn.
+var _ FetchFn = (*Replayer)(nil).Fetch
+
+// NewReplayer crée un Replayer à partir des interactions d'une cassette.
+func NewReplayer(interactions []Interaction, opts ...ReplayOption) *Replayer {
+	r := &Replayer{queues: make(map[string][]Interaction)}
+	for i, it := range interactions {
+		if i == 0 || it.Time.Before(r.origin) {
+			r.origin = it.Time
+		}
+		r.queues[it.UUID] = append(r.queues[it.UUID], it)
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Fetch retourne la prochaine interaction enregistrée pour uuid : le corps
+// JSON, ou l'erreur typée correspondant au statut enregistré. Avec
+// WithReplaySpeed, attend que l'instant d'origine (accéléré) soit atteint.
+func (r *Replayer) Fetch(ctx context.Context, uuid string) ([]byte, error) {
+	r.mu.Lock()
+	if r.started.IsZero() {
+		r.started = time.Now()
+	}
+	queue := r.queues[uuid]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrCassetteExhausted, SafeTruncate(uuid, 8))
+	}
+	it := queue[0]
+	r.queues[uuid] = queue[1:]
+	var wait time.Duration
+	if r.speed > 0 {
+		due := r.started.Add(time.Duration(float64(it.Time.Sub(r.origin)) / r.speed))
+		wait = time.Until(due)
+	}
+	r.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return it.Body, it.err()
+}
+
+// Remaining retourne le nombre d'interactions non encore rejouées.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, q := range r.queues {
+		n += len(q)
+	}
+	return n
+}
+
+// err reconstruit l'erreur d'une interaction à partir de son statut.
+func (it Interaction) err() error {
+	switch {
+	case it.Status == 200 && it.Error == "":
+		return nil
+	case it.Status == 200:
+		return fmt.Errorf("%w: %s", ErrSchema, it.Error)
+	case it.Status == 0:
+		return errors.New(it.Error)
+	default:
+		return errorForStatus(it.Status, "")
+	}
+}
+
+// statusOf retrouve le statut HTTP d'une erreur de fetch (200 si nil, 0 si
+// l'erreur n'est pas liée à un statut HTTP).
+func statusOf(err error) int {
+	if err == nil {
+		return 200
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	var rl *RateLimitError
+	if errors.As(err, &rl) {
+		return 429
+	}
+	return 0
+}
@@ -1,5 +1,7 @@
 package tracker
 
+import "time"
+
 // Constantes partagées par api.go et browser.go.
 
 // defaultUserAgent est le User-Agent envoyé à la fois par le client TLS et
@@ -7,6 +9,35 @@ package tracker
 // incohérences qui peuvent déclencher les protections anti-bot d'Uber.
 const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
 
-// browserSettleDelay est le temps laissé aux scripts anti-bot d'Uber pour
-// s'exécuter après le chargement de la page, avant de récupérer les cookies.
+// browserSettleDelay est le temps maximal laissé aux scripts anti-bot d'Uber
+// pour poser les cookies attendus après le chargement de la page.
 const browserSettleDelay = 8 // secondes
+
+// Valeurs par défaut d'un Client (voir NewClient).
+const (
+	defaultBaseURL        = "https://www.ubereats.com"
+	defaultLocale         = "fr"
+	defaultTimezone       = "Europe/Paris"
+	defaultAcceptLanguage = "fr-FR,fr;q=0.9,en-US;q=0.8,en;q=0.7"
+	defaultTimeout        = 30 * time.Second
+
+	// defaultRefreshMargin est l'avance prise pour renouveler un cookie critique.
+	defaultRefreshMargin = 2 * time.Minute
+
+	// activeOrdersPath est le chemin de l'endpoint de suivi, relatif à l'URL de base.
+	activeOrdersPath = "/_p/api/getActiveOrdersV1"
+)
+
+// defaultCriticalCookies sont les cookies de session sans lesquels l'API
+// répond 401/403 ; leur expiration déclenche un renouvellement proactif.
+var defaultCriticalCookies = []string{"jwt-session", "sid"}
+
+// defaultStallThreshold est la durée d'immobilité au-delà de laquelle un
+// livreur en route est signalé comme bloqué (voir Manager.WithStallThreshold).
+const defaultStallThreshold = 4 * time.Minute
+
+// Budget par défaut d'un RateLimiter créé avec des valeurs invalides.
+const (
+	defaultRequestsPerSecond = 2.0
+	defaultRequestBurst      = 5
+)
@@ -0,0 +1,197 @@
+package tracker
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ==========================================
+// Contacts du livreur et du restaurant
+// ==========================================
+
+// ContactRole distingue les contacts d'une commande.
+type ContactRole string
+
+// Rôles d'un contact.
+const (
+	RoleUnknown    ContactRole = ""
+	RoleCourier    ContactRole = "COURIER"
+	RoleRestaurant ContactRole = "RESTAURANT"
+)
+
+// Role retourne le rôle du contact : son Type s'il est renseigné, sinon
+// RoleRestaurant s'il porte le nom du restaurant. Les autres contacts sont
+// RoleUnknown ; ExtractContacts les rapproche du livreur de la commande.
+func (c Contact) Role(restaurant string) ContactRole {
+	switch ContactRole(strings.ToUpper(c.Type)) {
+	case RoleCourier:
+		return RoleCourier
+	case RoleRestaurant:
+		return RoleRestaurant
+	}
+	if c.Title != "" && restaurant != "" && strings.EqualFold(strings.TrimSpace(c.Title), strings.TrimSpace(restaurant)) {
+		return RoleRestaurant
+	}
+	return RoleUnknown
+}
+
+// contactRoles retourne le rôle de chaque contact de o. Sans Type, un contact
+// est le livreur s'il porte le nom du livreur des FeedCards, ou s'il est le
+// seul contact non classé d'une commande à laquelle un livreur est affecté.
+func contactRoles(o Order) []ContactRole {
+	roles := make([]ContactRole, len(o.Contacts))
+	var unknown []int
+	hasCourier := false
+	for i, c := range o.Contacts {
+		roles[i] = c.Role(o.ActiveOrderOverview.Title)
+		switch roles[i] {
+		case RoleUnknown:
+			unknown = append(unknown, i)
+		case RoleCourier:
+			hasCourier = true
+		}
+	}
+	if hasCourier || len(unknown) == 0 {
+		return roles
+	}
+
+	if name := strings.TrimSpace(detailsOf(o).courier.Name); name != "" {
+		for _, i := range unknown {
+			if strings.EqualFold(strings.TrimSpace(o.Contacts[i].Title), name) {
+				roles[i] = RoleCourier
+				return roles
+			}
+		}
+	}
+	if len(unknown) == 1 && courierAssigned(o) {
+		roles[unknown[0]] = RoleCourier
+	}
+	return roles
+}
+
+// courierAssigned indique si une FeedCard de o décrit un livreur.
+func courierAssigned(o Order) bool {
+	for _, fc := range o.FeedCards {
+		if len(fc.Courier) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// PhoneRedaction définit le masquage des numéros de téléphone exposés.
+type PhoneRedaction int
+
+const (
+	// RedactNone expose le numéro tel quel (défaut).
+	RedactNone PhoneRedaction = iota
+	// RedactPartial masque les chiffres sauf les deux derniers : "+•• • •• •• •• 78".
+	RedactPartial
+	// RedactFull retire le numéro.
+	RedactFull
+)
+
+// RedactPhone applique mode au numéro phone.
+func RedactPhone(phone string, mode PhoneRedaction) string {
+	switch mode {
+	case RedactFull:
+		return ""
+	case RedactPartial:
+		keep := 2
+		runes := []rune(phone)
+		for i := len(runes) - 1; i >= 0; i-- {
+			if !unicode.IsDigit(runes[i]) {
+				continue
+			}
+			if keep > 0 {
+				keep--
+				continue
+			}
+			runes[i] = '•'
+		}
+		return string(runes)
+	default:
+		return phone
+	}
+}
+
+// CourierProfile est le profil du livreur. Rating vaut 0 si inconnue.
+type CourierProfile struct {
+	Name        string
+	Phone       string
+	VehicleType string
+	Rating      float64
+	PhotoURL    string
+}
+
+// RestaurantContact est le contact du restaurant.
+type RestaurantContact struct {
+	Name  string
+	Phone string
+}
+
+// OrderContacts regroupe les contacts d'une commande (nil si absents).
+type OrderContacts struct {
+	Courier    *CourierProfile
+	Restaurant *RestaurantContact
+}
+
+// ExtractContacts construit les contacts d'un Order : les Contacts, classés
+// par rôle (voir Contact.Role), et le profil du livreur lu dans les FeedCards.
+func ExtractContacts(o Order) OrderContacts {
+	var oc OrderContacts
+	roles := contactRoles(o)
+	for i, c := range o.Contacts {
+		switch roles[i] {
+		case RoleCourier:
+			if oc.Courier == nil {
+				oc.Courier = &CourierProfile{Name: c.Title, Phone: c.FormattedPhoneNumber}
+			}
+		case RoleRestaurant:
+			if oc.Restaurant == nil {
+				oc.Restaurant = &RestaurantContact{Name: c.Title, Phone: c.FormattedPhoneNumber}
+			}
+		}
+	}
+
+	if profile := detailsOf(o).courier; profile != (CourierInfo{}) {
+		if oc.Courier == nil {
+			oc.Courier = &CourierProfile{}
+		}
+		if profile.Name != "" {
+			oc.Courier.Name = profile.Name
+		}
+		oc.Courier.VehicleType = profile.VehicleType
+		oc.Courier.Rating = profile.Rating
+		oc.Courier.PhotoURL = profile.PictureURL
+	}
+	return oc
+}
+
+// redactOrder retourne o dont les numéros des contacts sont masqués selon mode.
+func redactOrder(o Order, mode PhoneRedaction) Order {
+	if mode == RedactNone || len(o.Contacts) == 0 {
+		return o
+	}
+	o.Contacts = slices.Clone(o.Contacts)
+	for i := range o.Contacts {
+		o.Contacts[i].FormattedPhoneNumber = RedactPhone(o.Contacts[i].FormattedPhoneNumber, mode)
+	}
+	return o
+}
+
+// Redacted retourne une copie de c dont les numéros sont masqués selon mode.
+func (c OrderContacts) Redacted(mode PhoneRedaction) OrderContacts {
+	if c.Courier != nil {
+		courier := *c.Courier
+		courier.Phone = RedactPhone(courier.Phone, mode)
+		c.Courier = &courier
+	}
+	if c.Restaurant != nil {
+		restaurant := *c.Restaurant
+		restaurant.Phone = RedactPhone(restaurant.Phone, mode)
+		c.Restaurant = &restaurant
+	}
+	return c
+}
@@ -0,0 +1,166 @@
+package tracker
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cookie est un cookie Uber avec ses métadonnées (domaine, chemin, expiration).
+type Cookie struct {
+	Name     string
+	Value    string
+	Domain   string
+	Path     string
+	Expires  time.Time // zéro = cookie de session
+	HTTPOnly bool
+	Secure   bool
+}
+
+// Expired indique si le cookie est expiré à l'instant now.
+func (c Cookie) Expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// CookieJar est un ensemble de cookies typés, sûr en accès concurrent.
+// Un cookie est identifié par son triplet (nom, domaine, chemin).
+type CookieJar struct {
+	mu      sync.RWMutex
+	cookies []Cookie
+}
+
+// NewCookieJar crée un jar contenant cookies.
+func NewCookieJar(cookies ...Cookie) *CookieJar {
+	j := &CookieJar{}
+	for _, c := range cookies {
+		j.Set(c)
+	}
+	return j
+}
+
+// ParseCookieHeader construit un jar à partir d'un header Cookie
+// ("nom=valeur; nom2=valeur2"). Les métadonnées sont inconnues.
+func ParseCookieHeader(header string) *CookieJar {
+	j := &CookieJar{}
+	for _, part := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || name == "" {
+			continue
+		}
+		j.Set(Cookie{Name: name, Value: value})
+	}
+	return j
+}
+
+// Set ajoute ou remplace un cookie. Un cookie déjà expiré supprime l'entrée
+// existante (c'est ainsi qu'un serveur efface un cookie).
+func (j *CookieJar) Set(c Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i, existing := range j.cookies {
+		if sameCookie(existing, c) {
+			if c.Expired(time.Now()) {
+				j.cookies = append(j.cookies[:i], j.cookies[i+1:]...)
+			} else {
+				j.cookies[i] = c
+			}
+			return
+		}
+	}
+	if !c.Expired(time.Now()) {
+		j.cookies = append(j.cookies, c)
+	}
+}
+
+// MergeSetCookie intègre les headers Set-Cookie d'une réponse HTTP.
+// Retourne le nombre de cookies valides pris en compte.
+func (j *CookieJar) MergeSetCookie(headers []string) int {
+	merged := 0
+	for _, h := range headers {
+		hc, err := http.ParseSetCookie(h)
+		if err != nil {
+			continue
+		}
+		c := Cookie{
+			Name:     hc.Name,
+			Value:    hc.Value,
+			Domain:   hc.Domain,
+			Path:     hc.Path,
+			Expires:  hc.Expires,
+			HTTPOnly: hc.HttpOnly,
+			Secure:   hc.Secure,
+		}
+		switch {
+		case hc.MaxAge < 0:
+			c.Expires = time.Unix(1, 0)
+		case hc.MaxAge > 0:
+			c.Expires = time.Now().Add(time.Duration(hc.MaxAge) * time.Second)
+		}
+		j.Set(c)
+		merged++
+	}
+	return merged
+}
+
+// Cookies retourne une copie des cookies non expirés.
+func (j *CookieJar) Cookies() []Cookie {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	now := time.Now()
+	out := make([]Cookie, 0, len(j.cookies))
+	for _, c := range j.cookies {
+		if !c.Expired(now) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Len retourne le nombre de cookies non expirés.
+func (j *CookieJar) Len() int {
+	return len(j.Cookies())
+}
+
+// Header formate les cookies non expirés pour le header Cookie.
+func (j *CookieJar) Header() string {
+	cookies := j.Cookies()
+	parts := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		parts = append(parts, c.Name+"="+c.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// EarliestExpiry retourne la plus proche expiration parmi les cookies nommés
+// (tous les cookies si names est vide). ok vaut false si aucun d'eux n'a
+// d'expiration connue.
+func (j *CookieJar) EarliestExpiry(names ...string) (earliest time.Time, ok bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	for _, c := range j.cookies {
+		if c.Expires.IsZero() || (len(names) > 0 && !slices.Contains(names, c.Name)) {
+			continue
+		}
+		if !ok || c.Expires.Before(earliest) {
+			earliest, ok = c.Expires, true
+		}
+	}
+	return earliest, ok
+}
+
+// sameCookie compare l'identité (nom, domaine, chemin) de deux cookies.
+// Un domaine ou chemin vide (cookie sans métadonnées) correspond à tous.
+func sameCookie(a, b Cookie) bool {
+	if a.Name != b.Name {
+		return false
+	}
+	if a.Domain != "" && b.Domain != "" && strings.TrimPrefix(a.Domain, ".") != strings.TrimPrefix(b.Domain, ".") {
+		return false
+	}
+	return a.Path == "" || b.Path == "" || a.Path == b.Path
+}
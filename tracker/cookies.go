@@ -0,0 +1,432 @@
+package tracker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Vérifications compile-time : les fournisseurs intégrés satisfont CookieProvider.
+var (
+	_ CookieProvider = (*MemoryCookieProvider)(nil)
+	_ CookieProvider = (*FileCookieProvider)(nil)
+	_ CookieProvider = (*BrowserCookieProvider)(nil)
+)
+
+// errNoRefresher est retournée par un fournisseur incapable de renouveler seul ses cookies.
+var errNoRefresher = errors.New("aucun fournisseur de renouvellement configuré")
+
+// cookieSaver est implémenté par les fournisseurs capables de persister leur
+// jar après que le Client y a intégré des Set-Cookie.
+type cookieSaver interface {
+	Save() error
+}
+
+// ==========================================
+// MemoryCookieProvider
+// ==========================================
+
+// MemoryCookieProvider garde les cookies en mémoire. Le renouvellement est
+// délégué au fournisseur refresher s'il est fourni.
+type MemoryCookieProvider struct {
+	mu        sync.RWMutex
+	jar       *CookieJar
+	refresher CookieProvider
+}
+
+// NewMemoryCookieProvider crée un fournisseur en mémoire initialisé avec jar
+// (nil = vide). refresher est optionnel : sans lui, Refresh retourne une erreur.
+func NewMemoryCookieProvider(jar *CookieJar, refresher ...CookieProvider) *MemoryCookieProvider {
+	if jar == nil {
+		jar = NewCookieJar()
+	}
+	p := &MemoryCookieProvider{jar: jar}
+	if len(refresher) > 0 {
+		p.refresher = refresher[0]
+	}
+	return p
+}
+
+// Get retourne le jar en mémoire.
+func (p *MemoryCookieProvider) Get(_ context.Context) (*CookieJar, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.jar, nil
+}
+
+// Set remplace le jar en mémoire (ex : cookies injectés depuis l'extérieur).
+func (p *MemoryCookieProvider) Set(jar *CookieJar) {
+	if jar == nil {
+		jar = NewCookieJar()
+	}
+	p.mu.Lock()
+	p.jar = jar
+	p.mu.Unlock()
+}
+
+// Refresh délègue au refresher et mémorise le résultat.
+func (p *MemoryCookieProvider) Refresh(ctx context.Context, orderURL string) (*CookieJar, error) {
+	if p.refresher == nil {
+		return nil, errNoRefresher
+	}
+	jar, err := p.refresher.Refresh(ctx, orderURL)
+	if err != nil {
+		return nil, err
+	}
+	p.Set(jar)
+	return jar, nil
+}
+
+// Invalidate signale le refus des cookies au refresher. Le jar courant est
+// conservé jusqu'à ce qu'un Refresh réussi le remplace : un renouvellement
+// en échec ne laisse pas le fournisseur sans cookies.
+func (p *MemoryCookieProvider) Invalidate() {
+	if p.refresher != nil {
+		p.refresher.Invalidate()
+	}
+}
+
+// ==========================================
+// FileCookieProvider
+// ==========================================
+
+// FileCookieProvider lit et écrit les cookies dans un fichier, au format
+// Netscape (cookies.txt) ou JSON (extension .json), pour qu'ils survivent aux
+// redémarrages et puissent être fournis par un outil externe.
+type FileCookieProvider struct {
+	path      string
+	refresher CookieProvider
+
+	mu  sync.Mutex
+	jar *CookieJar
+}
+
+// NewFileCookieProvider crée un fournisseur adossé au fichier path.
+// refresher est optionnel : s'il est fourni, les cookies renouvelés sont
+// réécrits dans le fichier.
+func NewFileCookieProvider(path string, refresher ...CookieProvider) *FileCookieProvider {
+	p := &FileCookieProvider{path: path}
+	if len(refresher) > 0 {
+		p.refresher = refresher[0]
+	}
+	return p
+}
+
+// Get retourne les cookies du fichier, lus une seule fois puis gardés en mémoire.
+// Un fichier absent n'est pas une erreur (jar vide).
+func (p *FileCookieProvider) Get(_ context.Context) (*CookieJar, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.jar != nil {
+		return p.jar, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		p.jar = NewCookieJar()
+		return p.jar, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lecture fichier cookies: %w", err)
+	}
+
+	cookies, err := parseCookieFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("fichier cookies %s: %w", p.path, err)
+	}
+	p.jar = NewCookieJar(cookies...)
+	return p.jar, nil
+}
+
+// Refresh délègue au refresher puis persiste les nouveaux cookies.
+func (p *FileCookieProvider) Refresh(ctx context.Context, orderURL string) (*CookieJar, error) {
+	if p.refresher == nil {
+		return nil, errNoRefresher
+	}
+	jar, err := p.refresher.Refresh(ctx, orderURL)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.jar = jar
+	p.mu.Unlock()
+	if err := p.Save(); err != nil {
+		return nil, err
+	}
+	return jar, nil
+}
+
+// Invalidate oublie les cookies en mémoire ; le fichier sera relu au prochain Get.
+func (p *FileCookieProvider) Invalidate() {
+	p.mu.Lock()
+	p.jar = nil
+	p.mu.Unlock()
+	if p.refresher != nil {
+		p.refresher.Invalidate()
+	}
+}
+
+// Save écrit le jar courant de façon atomique (fichier temporaire + rename).
+func (p *FileCookieProvider) Save() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.jar == nil {
+		return nil
+	}
+
+	cookies := p.jar.Cookies()
+	var data []byte
+	if strings.EqualFold(filepath.Ext(p.path), ".json") {
+		dto := make([]fileCookie, 0, len(cookies))
+		for _, c := range cookies {
+			dto = append(dto, toFileCookie(c))
+		}
+		var err error
+		data, err = json.MarshalIndent(dto, "", "  ")
+		if err != nil {
+			return fmt.Errorf("sérialisation cookies: %w", err)
+		}
+	} else {
+		data = formatNetscape(cookies)
+	}
+
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("écriture fichier cookies: %w", err)
+	}
+	if err := os.Rename(tmp, p.path); err != nil {
+		return fmt.Errorf("écriture fichier cookies: %w", err)
+	}
+	return nil
+}
+
+// ==========================================
+// BrowserCookieProvider
+// ==========================================
+
+// BrowserCookieProvider obtient les cookies via un navigateur headless
+// (BrowserPool). Lent : à réserver au renouvellement après un 401/403.
+type BrowserCookieProvider struct {
+	userAgent string
+	pool      *BrowserPool // nil = pool partagé par défaut
+
+	mu  sync.RWMutex
+	jar *CookieJar
+}
+
+// NewBrowserCookieProvider crée un fournisseur navigateur. userAgent est
+// optionnel (défaut : celui du client TLS par défaut).
+func NewBrowserCookieProvider(userAgent ...string) *BrowserCookieProvider {
+	ua := defaultUserAgent
+	if len(userAgent) > 0 && userAgent[0] != "" {
+		ua = userAgent[0]
+	}
+	return &BrowserCookieProvider{userAgent: ua, jar: NewCookieJar()}
+}
+
+// UsePool fait passer les renouvellements par pool au lieu du pool par défaut.
+func (p *BrowserCookieProvider) UsePool(pool *BrowserPool) *BrowserCookieProvider {
+	p.pool = pool
+	return p
+}
+
+// Get retourne les derniers cookies obtenus par le navigateur (jar vide au départ).
+func (p *BrowserCookieProvider) Get(_ context.Context) (*CookieJar, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.jar, nil
+}
+
+// Refresh ouvre orderURL dans le navigateur et mémorise les cookies obtenus.
+// Le navigateur passe par le proxy du Client appelant (ProxyFromContext).
+func (p *BrowserCookieProvider) Refresh(ctx context.Context, orderURL string) (*CookieJar, error) {
+	pool := p.pool
+	if pool == nil {
+		pool = defaultBrowserPool()
+	}
+	jar, err := pool.FreshCookies(ctx, orderURL, p.userAgent, ProxyFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.jar = jar
+	p.mu.Unlock()
+	return jar, nil
+}
+
+// Invalidate ne fait rien : comme pour MemoryCookieProvider, le jar courant
+// est conservé jusqu'à ce qu'un Refresh réussi le remplace.
+func (p *BrowserCookieProvider) Invalidate() {}
+
+// ==========================================
+// Formats de fichiers
+// ==========================================
+
+// fileCookie est la représentation d'un cookie dans les fichiers JSON.
+type fileCookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain,omitempty"`
+	Path     string  `json:"path,omitempty"`
+	Expires  float64 `json:"expires,omitempty"` // timestamp Unix en secondes, 0 = session
+	HTTPOnly bool    `json:"httpOnly,omitempty"`
+	Secure   bool    `json:"secure,omitempty"`
+}
+
+func toFileCookie(c Cookie) fileCookie {
+	fc := fileCookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		HTTPOnly: c.HTTPOnly,
+		Secure:   c.Secure,
+	}
+	if !c.Expires.IsZero() {
+		fc.Expires = float64(c.Expires.Unix())
+	}
+	return fc
+}
+
+func (fc fileCookie) toCookie() Cookie {
+	return Cookie{
+		Name:     fc.Name,
+		Value:    fc.Value,
+		Domain:   fc.Domain,
+		Path:     fc.Path,
+		Expires:  unixSeconds(fc.Expires),
+		HTTPOnly: fc.HTTPOnly,
+		Secure:   fc.Secure,
+	}
+}
+
+// unixSeconds convertit un timestamp (éventuellement fractionnaire) ; ≤ 0 = session.
+func unixSeconds(sec float64) time.Time {
+	if sec <= 0 {
+		return time.Time{}
+	}
+	whole, frac := math.Modf(sec)
+	return time.Unix(int64(whole), int64(frac*1e9))
+}
+
+// cookieFileDomain est le domaine écrit pour les cookies dont on ignore l'origine.
+const cookieFileDomain = ".ubereats.com"
+
+// parseCookieFile détecte le format (JSON ou Netscape) et retourne les cookies
+// non expirés.
+func parseCookieFile(data []byte) ([]Cookie, error) {
+	trimmed := bytes.TrimSpace(data)
+	var cookies []Cookie
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var dto []fileCookie
+		if err := json.Unmarshal(trimmed, &dto); err != nil {
+			return nil, fmt.Errorf("JSON invalide: %w", err)
+		}
+		for _, fc := range dto {
+			cookies = append(cookies, fc.toCookie())
+		}
+	} else {
+		var err error
+		if cookies, err = parseNetscape(data); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	valid := cookies[:0]
+	for _, c := range cookies {
+		if c.Name == "" || c.Expired(now) {
+			continue
+		}
+		valid = append(valid, c)
+	}
+	return valid, nil
+}
+
+// parseNetscape lit le format cookies.txt : 7 champs séparés par des tabulations
+// (domaine, sous-domaines, chemin, secure, expiration, nom, valeur).
+func parseNetscape(data []byte) ([]Cookie, error) {
+	var cookies []Cookie
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		// Seule la fin de ligne est retirée : la tabulation finale d'un cookie
+		// à valeur vide est significative.
+		text := strings.TrimRight(scanner.Text(), "\r\n")
+		httpOnly := false
+		if strings.HasPrefix(text, "#HttpOnly_") {
+			text = strings.TrimPrefix(text, "#HttpOnly_")
+			httpOnly = true
+		}
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("ligne %d: %d champs au lieu de 7", line, len(fields))
+		}
+		expires, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("ligne %d: expiration invalide %q", line, fields[4])
+		}
+		cookies = append(cookies, Cookie{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Expires:  unixSeconds(expires),
+			Name:     fields[5],
+			Value:    fields[6],
+			HTTPOnly: httpOnly,
+		})
+	}
+	return cookies, scanner.Err()
+}
+
+// formatNetscape sérialise les cookies au format cookies.txt.
+func formatNetscape(cookies []Cookie) []byte {
+	var b bytes.Buffer
+	b.WriteString("# Netscape HTTP Cookie File\n")
+	for _, c := range cookies {
+		domain := c.Domain
+		if domain == "" {
+			domain = cookieFileDomain
+		}
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+		prefix := ""
+		if c.HTTPOnly {
+			prefix = "#HttpOnly_"
+		}
+		var expires int64
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Unix()
+		}
+		fmt.Fprintf(&b, "%s%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			prefix, domain, netscapeBool(strings.HasPrefix(domain, ".")), path,
+			netscapeBool(c.Secure), expires, c.Name, c.Value)
+	}
+	return b.Bytes()
+}
+
+func netscapeBool(v bool) string {
+	if v {
+		return "TRUE"
+	}
+	return "FALSE"
+}
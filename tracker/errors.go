@@ -0,0 +1,104 @@
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Erreurs sentinelles exposées par FetchUberJSON, Client.Fetch et le worker.
+// Elles s'utilisent avec errors.Is ; les détails (code HTTP, Retry-After) sont
+// accessibles via errors.As sur *HTTPError et *RateLimitError.
+var (
+	// ErrUnauthorized : l'API refuse l'identité (401/403), même après renouvellement des cookies.
+	ErrUnauthorized = errors.New("accès refusé par l'API")
+	// ErrRateLimited : l'API limite le débit (429).
+	ErrRateLimited = errors.New("limite de requêtes atteinte")
+	// ErrServer : erreur côté Uber (5xx).
+	ErrServer = errors.New("erreur serveur Uber")
+	// ErrNetwork : la requête n'a pas abouti (DNS, TLS, timeout…).
+	ErrNetwork = errors.New("erreur réseau")
+	// ErrSchema : la réponse n'est pas un JSON exploitable.
+	ErrSchema = errors.New("réponse JSON invalide")
+	// ErrOrderNotFound : l'API ne retourne aucune commande pour cet UUID.
+	ErrOrderNotFound = errors.New("commande introuvable")
+)
+
+// defaultRetryAfter est l'attente appliquée après un 429 sans header Retry-After.
+const defaultRetryAfter = 60 * time.Second
+
+// HTTPError est retournée pour un code HTTP inattendu. errors.Is la rapproche
+// de la sentinelle correspondante (ErrUnauthorized, ErrOrderNotFound, ErrServer).
+type HTTPError struct {
+	StatusCode int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("erreur API HTTP: %d", e.StatusCode)
+}
+
+// Is permet errors.Is(err, ErrUnauthorized) etc. sur une *HTTPError.
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrOrderNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// RateLimitError est retournée sur un 429. RetryAfter vaut le délai demandé
+// par l'API (ou defaultRetryAfter s'il est absent).
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v (réessayer dans %s)", ErrRateLimited, e.RetryAfter)
+}
+
+// Is permet errors.Is(err, ErrRateLimited) sur une *RateLimitError.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RetryAfter retourne le délai demandé par l'API si err est une limitation de débit.
+func RetryAfter(err error) (time.Duration, bool) {
+	var rl *RateLimitError
+	if errors.As(err, &rl) {
+		return rl.RetryAfter, true
+	}
+	return 0, false
+}
+
+// errorForStatus convertit un code HTTP non-200 en erreur typée.
+func errorForStatus(status int, retryAfter string) error {
+	if status == http.StatusTooManyRequests {
+		return &RateLimitError{RetryAfter: parseRetryAfter(retryAfter, time.Now())}
+	}
+	return &HTTPError{StatusCode: status}
+}
+
+// parseRetryAfter lit un header Retry-After (secondes ou date HTTP).
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultRetryAfter
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return defaultRetryAfter
+}
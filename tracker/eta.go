@@ -0,0 +1,176 @@
+package tracker
+
+import (
+	"math"
+	"time"
+)
+
+// ==========================================
+// Estimation indépendante de l'ETA
+// ==========================================
+
+// ETAInput regroupe les observations d'un poll utilisées pour estimer l'ETA.
+type ETAInput struct {
+	At            time.Time
+	UberETA       int // minutes lues dans le LABEL, -1 = absent
+	Phase         OrderPhase
+	Progress      int
+	TotalProgress int
+	Motion        CourierMotion
+	// EnRoute indique si le livreur apporte la commande (ReconcileResult.EnRoute) :
+	// la distance restante n'est exploitée que dans ce cas.
+	EnRoute bool
+}
+
+// ETAEstimate est une estimation du temps restant.
+type ETAEstimate struct {
+	Minutes    int     // -1 = inconnu
+	Confidence float64 // 0 (aucune) à 1 (certaine)
+	Jumping    bool    // l'ETA d'Uber s'écarte brutalement de l'estimation précédente
+}
+
+// ETAEstimator estime l'ETA d'une commande à partir des polls successifs.
+// Une instance suit une seule commande (voir Manager.WithETAEstimator).
+type ETAEstimator interface {
+	Estimate(in ETAInput) ETAEstimate
+}
+
+// Paramètres de l'estimateur par défaut.
+const (
+	// etaRouteFactor corrige la distance à vol d'oiseau en distance routière.
+	etaRouteFactor = 1.3
+	// etaFallbackSpeed est la vitesse supposée d'un livreur (m/s, ≈ 15 km/h)
+	// tant que sa vitesse mesurée n'est pas significative.
+	etaFallbackSpeed = 4.0
+	etaMinSpeed      = 1.0
+	// etaSmoothing est le poids de la nouvelle estimation dans l'ETA lissé.
+	etaSmoothing = 0.5
+	// etaJumpMinutes est l'écart à partir duquel l'ETA d'Uber est jugé instable.
+	etaJumpMinutes = 5
+)
+
+// Confiance accordée à chaque source.
+const (
+	etaConfidenceUber     = 0.9
+	etaConfidenceJumping  = 0.4
+	etaConfidenceDistance = 0.6
+	etaConfidenceFallback = 0.35
+	etaConfidenceProgress = 0.3
+)
+
+// DefaultETAEstimator combine l'ETA d'Uber, la distance et la vitesse du
+// livreur, et le rythme de progression de la commande. Les estimations sont
+// pondérées par leur confiance puis lissées d'un poll à l'autre.
+type DefaultETAEstimator struct {
+	last       ETAEstimate
+	lastAt     time.Time
+	stepAt     time.Time // instant du dernier changement d'étape
+	stepStart  time.Time // instant de la première étape observée
+	stepFirst  int
+	stepLatest int
+}
+
+// Vérification compile-time : DefaultETAEstimator satisfait ETAEstimator.
+var _ ETAEstimator = (*DefaultETAEstimator)(nil)
+
+// NewETAEstimator crée l'estimateur par défaut.
+func NewETAEstimator() *DefaultETAEstimator {
+	return &DefaultETAEstimator{last: ETAEstimate{Minutes: -1}}
+}
+
+// Estimate met à jour l'estimation avec les observations du poll.
+func (e *DefaultETAEstimator) Estimate(in ETAInput) ETAEstimate {
+	if in.Phase.IsTerminal() {
+		e.last = ETAEstimate{Minutes: 0, Confidence: 1}
+		return e.last
+	}
+	e.observeProgress(in)
+
+	// Estimation précédente, vieillie du temps écoulé.
+	prev := -1.0
+	if e.last.Minutes >= 0 && !e.lastAt.IsZero() {
+		prev = math.Max(0, float64(e.last.Minutes)-in.At.Sub(e.lastAt).Minutes())
+	}
+
+	var sum, weights, confidence float64
+	add := func(minutes, conf float64) {
+		sum += minutes * conf
+		weights += conf
+		confidence = math.Max(confidence, conf)
+	}
+
+	jumping := false
+	if in.UberETA >= 0 {
+		conf := etaConfidenceUber
+		if prev >= 0 && math.Abs(float64(in.UberETA)-prev) > math.Max(etaJumpMinutes, prev/2) {
+			jumping = true
+			conf = etaConfidenceJumping
+		}
+		add(float64(in.UberETA), conf)
+	}
+	if m, conf, ok := distanceETA(in); ok {
+		add(m, conf)
+	}
+	if m, ok := e.progressETA(in); ok {
+		add(m, etaConfidenceProgress)
+	}
+
+	if weights == 0 {
+		if prev < 0 {
+			return ETAEstimate{Minutes: -1}
+		}
+		// Aucune source : l'estimation précédente continue de décroître, avec
+		// une confiance qui s'érode.
+		e.last = ETAEstimate{Minutes: int(math.Round(prev)), Confidence: e.last.Confidence / 2}
+		e.lastAt = in.At
+		return e.last
+	}
+
+	estimate := sum / weights
+	if prev >= 0 {
+		estimate = etaSmoothing*estimate + (1-etaSmoothing)*prev
+	}
+	e.last = ETAEstimate{Minutes: int(math.Round(estimate)), Confidence: confidence, Jumping: jumping}
+	e.lastAt = in.At
+	return e.last
+}
+
+// distanceETA estime l'ETA d'un livreur en route à partir de la distance
+// restante et de sa vitesse lissée.
+func distanceETA(in ETAInput) (minutes, confidence float64, ok bool) {
+	if !in.EnRoute || in.Motion.DistanceRemaining < 0 {
+		return 0, 0, false
+	}
+	speed, conf := in.Motion.SmoothedSpeed, etaConfidenceDistance
+	if speed < etaMinSpeed {
+		speed, conf = etaFallbackSpeed, etaConfidenceFallback
+	}
+	return in.Motion.DistanceRemaining * etaRouteFactor / speed / 60, conf, true
+}
+
+// observeProgress mémorise les changements d'étape de progression.
+func (e *DefaultETAEstimator) observeProgress(in ETAInput) {
+	if in.Progress <= 0 {
+		return
+	}
+	if e.stepStart.IsZero() {
+		e.stepStart, e.stepAt = in.At, in.At
+		e.stepFirst, e.stepLatest = in.Progress, in.Progress
+		return
+	}
+	if in.Progress > e.stepLatest {
+		e.stepLatest, e.stepAt = in.Progress, in.At
+	}
+}
+
+// progressETA extrapole le temps restant à partir de la durée moyenne des
+// étapes déjà franchies.
+func (e *DefaultETAEstimator) progressETA(in ETAInput) (float64, bool) {
+	steps := e.stepLatest - e.stepFirst
+	if steps <= 0 || in.TotalProgress <= e.stepLatest {
+		return 0, false
+	}
+	perStep := e.stepAt.Sub(e.stepStart).Minutes() / float64(steps)
+	remaining := float64(in.TotalProgress-e.stepLatest)*perStep - in.At.Sub(e.stepAt).Minutes()
+	return math.Max(0, remaining), true
+}
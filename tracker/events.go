@@ -0,0 +1,99 @@
+package tracker
+
+import (
+	"strconv"
+	"time"
+)
+
+// ==========================================
+// Événements sémantiques (diff entre deux polls)
+// ==========================================
+
+// EventKind identifie ce qui s'est passé entre deux polls d'une commande.
+type EventKind string
+
+// Types d'événements produits par Reconcile (et par le worker pour TrackingFailed
+// et SchemaDrift).
+const (
+	EventPhaseChanged     EventKind = "PHASE_CHANGED"     // Before/After : phase
+	EventProgressAdvanced EventKind = "PROGRESS_ADVANCED" // Before/After : étape de progression
+	EventETAChanged       EventKind = "ETA_CHANGED"       // Before/After : minutes ("-1" = inconnu)
+	EventCourierAssigned  EventKind = "COURIER_ASSIGNED"  // After : identifiant du livreur sur la carte
+	EventPINAvailable     EventKind = "PIN_AVAILABLE"     // After : code PIN
+	EventAddressConfirmed EventKind = "ADDRESS_CONFIRMED" // Before/After : adresse de livraison
+	EventOrderCancelled   EventKind = "ORDER_CANCELLED"   // Before : phase précédente
+	EventTrackingFailed   EventKind = "TRACKING_FAILED"   // After : raison de l'abandon
+	EventCourierStalled   EventKind = "COURIER_STALLED"   // After : durée d'immobilité
+	EventSchemaDrift      EventKind = "SCHEMA_DRIFT"      // Before/After : écarts de format (SchemaReport.String)
+)
+
+// Event est un changement typé, avec les valeurs avant/après (vides si sans objet).
+type Event struct {
+	Kind   EventKind
+	UUID   string
+	At     time.Time
+	Before string
+	After  string
+}
+
+// orderFacts résume un état de commande pour le calcul des événements.
+type orderFacts struct {
+	phase    OrderPhase
+	progress int
+	eta      int
+	courier  string
+	pin      string
+	address  string
+}
+
+// factsOf extrait d'un Order les valeurs suivies par les événements.
+func factsOf(o Order, phase OrderPhase, progress int) orderFacts {
+	kept := detailsOf(o)
+	f := orderFacts{
+		phase:    phase,
+		progress: progress,
+		eta:      ExtractETAFromOrder(o),
+		pin:      kept.pin,
+		address:  kept.address,
+	}
+	for _, card := range o.BackgroundFeedCards {
+		for _, entity := range card.MapEntity {
+			if entity.Type == "COURIER" && f.courier == "" {
+				f.courier = entity.UUID
+			}
+		}
+	}
+	return f
+}
+
+// diffEvents liste les événements entre before et after. Au premier poll
+// (before vide), les informations déjà disponibles sont annoncées.
+func diffEvents(uuid string, at time.Time, before, after orderFacts) []Event {
+	var events []Event
+	add := func(kind EventKind, from, to string) {
+		events = append(events, Event{Kind: kind, UUID: uuid, At: at, Before: from, After: to})
+	}
+
+	if after.phase != before.phase {
+		add(EventPhaseChanged, string(before.phase), string(after.phase))
+		if after.phase == PhaseCancelled {
+			add(EventOrderCancelled, string(before.phase), string(after.phase))
+		}
+	}
+	if after.progress > before.progress {
+		add(EventProgressAdvanced, strconv.Itoa(before.progress), strconv.Itoa(after.progress))
+	}
+	if after.eta >= 0 && after.eta != before.eta {
+		add(EventETAChanged, strconv.Itoa(before.eta), strconv.Itoa(after.eta))
+	}
+	if after.courier != "" && before.courier == "" {
+		add(EventCourierAssigned, "", after.courier)
+	}
+	if after.pin != "" && before.pin == "" {
+		add(EventPINAvailable, "", after.pin)
+	}
+	if after.address != "" && after.address != before.address {
+		add(EventAddressConfirmed, before.address, after.address)
+	}
+	return events
+}
@@ -0,0 +1,187 @@
+package tracker
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ==========================================
+// Export du trajet (GeoJSON, GPX)
+// ==========================================
+
+// Route est le trajet d'une livraison : la trace du livreur et les points
+// fixes (restaurant, destination). Voir RouteOf.
+type Route struct {
+	UUID        string
+	Trail       []Position
+	Restaurant  *Coordinates
+	Destination *Coordinates
+}
+
+// RouteOf construit le trajet d'une commande suivie : la trace enregistrée
+// par le worker, et les points fixes lus dans FullJSONData.
+func RouteOf(o TrackedOrder) Route {
+	r := Route{UUID: o.UUID, Trail: o.CourierTrail}
+	var resp Response
+	if json.Unmarshal([]byte(o.FullJSONData), &resp) == nil && len(resp.Data.Orders) > 0 {
+		order := resp.Data.Orders[0]
+		if c, ok := ExtractRestaurantPosition(order); ok {
+			r.Restaurant = &c
+		}
+		if c, ok := ExtractDestination(order); ok {
+			r.Destination = &c
+		}
+	}
+	return r
+}
+
+// --- GeoJSON (RFC 7946) ---
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// lonLat retourne c dans l'ordre GeoJSON [longitude, latitude].
+func lonLat(c Coordinates) [2]float64 {
+	return [2]float64{c.Longitude, c.Latitude}
+}
+
+// GeoJSON exporte le trajet en FeatureCollection : une LineString pour la
+// trace (horodatages et phases en propriétés), un Point par position relevée,
+// et un Point pour le restaurant et la destination.
+func (r Route) GeoJSON() ([]byte, error) {
+	fc := geoJSONCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	point := func(c Coordinates, props map[string]any) {
+		fc.Features = append(fc.Features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONGeometry{Type: "Point", Coordinates: lonLat(c)},
+			Properties: props,
+		})
+	}
+
+	if r.Restaurant != nil {
+		point(*r.Restaurant, map[string]any{"role": "restaurant"})
+	}
+	if r.Destination != nil {
+		point(*r.Destination, map[string]any{"role": "destination"})
+	}
+
+	if len(r.Trail) > 0 {
+		line := make([][2]float64, 0, len(r.Trail))
+		times := make([]string, 0, len(r.Trail))
+		phases := make([]string, 0, len(r.Trail))
+		for _, p := range r.Trail {
+			line = append(line, lonLat(p.Coordinates))
+			times = append(times, p.At.UTC().Format(time.RFC3339))
+			phases = append(phases, string(p.Phase))
+		}
+		// Une LineString exige au moins deux positions.
+		if len(line) >= 2 {
+			fc.Features = append(fc.Features, geoJSONFeature{
+				Type:     "Feature",
+				Geometry: geoJSONGeometry{Type: "LineString", Coordinates: line},
+				Properties: map[string]any{
+					"role":   "courier_trail",
+					"uuid":   r.UUID,
+					"times":  times,
+					"phases": phases,
+				},
+			})
+		}
+		for i, p := range r.Trail {
+			point(p.Coordinates, map[string]any{
+				"role":  "courier",
+				"index": i,
+				"time":  times[i],
+				"phase": string(p.Phase),
+				"eta":   p.ETA,
+			})
+		}
+	}
+
+	data, err := json.Marshal(fc)
+	if err != nil {
+		return nil, fmt.Errorf("export GeoJSON: %w", err)
+	}
+	return data, nil
+}
+
+// --- GPX 1.1 ---
+
+type gpxDoc struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Xmlns     string        `xml:"xmlns,attr"`
+	Waypoints []gpxWaypoint `xml:"wpt"`
+	Tracks    []gpxTrack    `xml:"trk"`
+}
+
+type gpxWaypoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Time string  `xml:"time,omitempty"`
+	Name string  `xml:"name,omitempty"`
+	Desc string  `xml:"desc,omitempty"`
+	Type string  `xml:"type,omitempty"`
+}
+
+type gpxTrack struct {
+	Name     string       `xml:"name"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxWaypoint `xml:"trkpt"`
+}
+
+// GPX exporte le trajet en GPX 1.1 : un waypoint pour le restaurant et la
+// destination, et une trace dont chaque point porte son horodatage, la phase
+// (type) et l'ETA (desc).
+func (r Route) GPX() ([]byte, error) {
+	doc := gpxDoc{Version: "1.1", Creator: "ubertracker", Xmlns: "http://www.topografix.com/GPX/1/1"}
+	if r.Restaurant != nil {
+		doc.Waypoints = append(doc.Waypoints, gpxWaypoint{Lat: r.Restaurant.Latitude, Lon: r.Restaurant.Longitude, Name: "Restaurant"})
+	}
+	if r.Destination != nil {
+		doc.Waypoints = append(doc.Waypoints, gpxWaypoint{Lat: r.Destination.Latitude, Lon: r.Destination.Longitude, Name: "Destination"})
+	}
+
+	if len(r.Trail) > 0 {
+		seg := gpxSegment{Points: make([]gpxWaypoint, 0, len(r.Trail))}
+		for _, p := range r.Trail {
+			pt := gpxWaypoint{
+				Lat:  p.Latitude,
+				Lon:  p.Longitude,
+				Time: p.At.UTC().Format(time.RFC3339),
+				Type: string(p.Phase),
+			}
+			if p.ETA >= 0 {
+				pt.Desc = "ETA " + strconv.Itoa(p.ETA) + " min"
+			}
+			seg.Points = append(seg.Points, pt)
+		}
+		doc.Tracks = []gpxTrack{{Name: "Livreur " + SafeTruncate(r.UUID, 8), Segments: []gpxSegment{seg}}}
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("export GPX: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,398 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================================
+// Historique JSON Patch (RFC 6902) des snapshots
+// ==========================================
+
+// ErrInvalidPatch est retournée quand un patch ne s'applique pas au document.
+var ErrInvalidPatch = errors.New("patch JSON invalide")
+
+// PatchOp est une opération JSON Patch. Seules add, remove, replace et test
+// sont produites ou acceptées.
+type PatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"` // JSON Pointer (RFC 6901), "" = document entier
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch est une suite d'opérations appliquées dans l'ordre.
+type Patch []PatchOp
+
+// HistoryEntry est un patch horodaté entre deux snapshots fusionnés successifs.
+type HistoryEntry struct {
+	At    time.Time `json:"at"`
+	Patch Patch     `json:"patch"`
+}
+
+// DiffJSON calcule le patch qui transforme before en after. Un before vide
+// (premier snapshot) produit un remplacement du document entier.
+func DiffJSON(before, after []byte) (Patch, error) {
+	b, err := decodeJSON(before)
+	if err != nil {
+		return nil, fmt.Errorf("diff JSON (avant): %w", err)
+	}
+	a, err := decodeJSON(after)
+	if err != nil {
+		return nil, fmt.Errorf("diff JSON (après): %w", err)
+	}
+	var p Patch
+	if err := diffValues(&p, "", b, a); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ApplyPatch applique p à doc (vide = null) et retourne le document obtenu.
+func ApplyPatch(doc []byte, p Patch) ([]byte, error) {
+	v, err := decodeJSON(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	for i, op := range p {
+		if v, err = applyOp(v, op); err != nil {
+			return nil, fmt.Errorf("%w: opération %d (%s %s): %w", ErrInvalidPatch, i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(v)
+}
+
+// ReplayHistory reconstruit le snapshot tel qu'il était à l'instant at, en
+// appliquant dans l'ordre les patchs horodatés jusqu'à at inclus. Le document
+// obtenu est équivalent au snapshot d'origine (mêmes valeurs), pas identique
+// octet par octet. Retourne nil si aucun patch n'est antérieur à at.
+func ReplayHistory(history []HistoryEntry, at time.Time) ([]byte, error) {
+	var doc []byte
+	for _, e := range history {
+		if e.At.After(at) {
+			break
+		}
+		var err error
+		if doc, err = ApplyPatch(doc, e.Patch); err != nil {
+			return nil, fmt.Errorf("patch du %s: %w", e.At.Format(time.RFC3339), err)
+		}
+	}
+	return doc, nil
+}
+
+// RebuildOrder reconstruit la réponse fusionnée d'une commande à l'instant at,
+// à partir de l'historique conservé par store.
+func RebuildOrder(ctx context.Context, store HistoryStore, uuid string, at time.Time) (Response, error) {
+	history, err := store.GetHistory(ctx, uuid)
+	if err != nil {
+		return Response{}, fmt.Errorf("lecture de l'historique: %w", err)
+	}
+	doc, err := ReplayHistory(history, at)
+	if err != nil {
+		return Response{}, err
+	}
+	if doc == nil {
+		return Response{}, fmt.Errorf("%w: aucun snapshot avant %s", ErrOrderNotFound, at.Format(time.RFC3339))
+	}
+	var resp Response
+	if err := json.Unmarshal(doc, &resp); err != nil {
+		return Response{}, fmt.Errorf("%w: %w", ErrSchema, err)
+	}
+	return resp, nil
+}
+
+// appendHistory enregistre le patch d'un snapshot persisté, si le store
+// implémente HistoryStore.
+func appendHistory(ctx context.Context, store OrderStore, uuid string, e HistoryEntry) error {
+	hs, ok := store.(HistoryStore)
+	if !ok || len(e.Patch) == 0 {
+		return nil
+	}
+	return hs.AppendPatch(ctx, uuid, e)
+}
+
+// needsHistoryBaseline indique si le prochain patch enregistré pour uuid doit
+// contenir le snapshot entier : historique encore vide, ou qui ne reconstruit
+// plus le snapshot persisté (patch perdu après un échec d'AppendPatch).
+func needsHistoryBaseline(ctx context.Context, store OrderStore, uuid string) bool {
+	hs, ok := store.(HistoryStore)
+	if !ok {
+		return false
+	}
+	history, err := hs.GetHistory(ctx, uuid)
+	if err != nil {
+		slog.Warn("lecture de l'historique échouée", "uuid", SafeTruncate(uuid, 8), "error", err)
+		return true
+	}
+	if len(history) == 0 {
+		return true
+	}
+	_, _, _, snapshot, err := store.GetSnapshot(ctx, uuid)
+	if err != nil || snapshot == "" {
+		// Sans snapshot, le prochain patch remplace déjà le document entier.
+		return false
+	}
+	var doc []byte
+	for _, e := range history {
+		if doc, err = ApplyPatch(doc, e.Patch); err != nil {
+			slog.Warn("historique illisible, nouvelle base", "uuid", SafeTruncate(uuid, 8), "error", err)
+			return true
+		}
+	}
+	replayed, err1 := decodeJSON(doc)
+	stored, err2 := decodeJSON([]byte(snapshot))
+	if err1 != nil || err2 != nil || !reflect.DeepEqual(replayed, stored) {
+		slog.Warn("historique désynchronisé du snapshot, nouvelle base", "uuid", SafeTruncate(uuid, 8))
+		return true
+	}
+	return false
+}
+
+// --- Diff ---
+
+// decodeJSON décode data en valeurs génériques ; vide = null.
+func decodeJSON(data []byte) (any, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// diffValues ajoute à p les opérations transformant b en a, sous path.
+func diffValues(p *Patch, path string, b, a any) error {
+	if reflect.DeepEqual(b, a) {
+		return nil
+	}
+	switch bv := b.(type) {
+	case map[string]any:
+		if av, ok := a.(map[string]any); ok {
+			return diffObjects(p, path, bv, av)
+		}
+	case []any:
+		if av, ok := a.([]any); ok {
+			return diffArrays(p, path, bv, av)
+		}
+	}
+	return addOp(p, "replace", path, a)
+}
+
+func diffObjects(p *Patch, path string, b, a map[string]any) error {
+	keys := make([]string, 0, len(b)+len(a))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		child := path + "/" + escapePointer(k)
+		bv, inB := b[k]
+		av, inA := a[k]
+		var err error
+		switch {
+		case !inA:
+			*p = append(*p, PatchOp{Op: "remove", Path: child})
+		case !inB:
+			err = addOp(p, "add", child, av)
+		default:
+			err = diffValues(p, child, bv, av)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffArrays compare les éléments de même indice, puis ajoute ou retire la fin.
+func diffArrays(p *Patch, path string, b, a []any) error {
+	common := min(len(b), len(a))
+	for i := range common {
+		if err := diffValues(p, path+"/"+strconv.Itoa(i), b[i], a[i]); err != nil {
+			return err
+		}
+	}
+	for i := common; i < len(a); i++ {
+		if err := addOp(p, "add", path+"/"+strconv.Itoa(i), a[i]); err != nil {
+			return err
+		}
+	}
+	// Retrait par la fin, pour que les indices restent valides.
+	for i := len(b) - 1; i >= common; i-- {
+		*p = append(*p, PatchOp{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+	}
+	return nil
+}
+
+func addOp(p *Patch, op, path string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("diff JSON %s: %w", path, err)
+	}
+	*p = append(*p, PatchOp{Op: op, Path: path, Value: raw})
+	return nil
+}
+
+// --- Application ---
+
+// escapePointer échappe un segment de JSON Pointer (RFC 6901).
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+// parsePointer découpe un JSON Pointer en segments décodés.
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("pointeur %q sans « / » initial", path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+// applyOp applique op à doc et retourne le nouveau document.
+func applyOp(doc any, op PatchOp) (any, error) {
+	tokens, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if op.Op != "remove" {
+		if value, err = decodeJSON(op.Value); err != nil {
+			return nil, err
+		}
+	}
+	switch op.Op {
+	case "add", "replace", "remove":
+		return applyAt(doc, tokens, op.Op, value)
+	case "test":
+		current, err := lookup(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, errors.New("test échoué")
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("opération %q non prise en charge", op.Op)
+	}
+}
+
+// applyAt applique add/replace/remove à l'emplacement tokens de doc.
+func applyAt(doc any, tokens []string, op string, value any) (any, error) {
+	if len(tokens) == 0 {
+		if op == "remove" {
+			return nil, nil
+		}
+		return value, nil
+	}
+	head, rest := tokens[0], tokens[1:]
+
+	switch node := doc.(type) {
+	case map[string]any:
+		child, exists := node[head]
+		if len(rest) > 0 {
+			if !exists {
+				return nil, fmt.Errorf("clé %q absente", head)
+			}
+			updated, err := applyAt(child, rest, op, value)
+			if err != nil {
+				return nil, err
+			}
+			node[head] = updated
+			return node, nil
+		}
+		switch op {
+		case "remove":
+			if !exists {
+				return nil, fmt.Errorf("clé %q absente", head)
+			}
+			delete(node, head)
+		case "replace":
+			if !exists {
+				return nil, fmt.Errorf("clé %q absente", head)
+			}
+			node[head] = value
+		default:
+			node[head] = value
+		}
+		return node, nil
+
+	case []any:
+		if len(rest) == 0 && op == "add" && head == "-" {
+			return append(node, value), nil
+		}
+		i, err := strconv.Atoi(head)
+		if err != nil || i < 0 || i > len(node) || (i == len(node) && (op != "add" || len(rest) > 0)) {
+			return nil, fmt.Errorf("indice %q hors limites (%d éléments)", head, len(node))
+		}
+		if len(rest) > 0 {
+			updated, err := applyAt(node[i], rest, op, value)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = updated
+			return node, nil
+		}
+		switch op {
+		case "remove":
+			return slices.Delete(node, i, i+1), nil
+		case "replace":
+			node[i] = value
+			return node, nil
+		default:
+			return slices.Insert(node, i, value), nil
+		}
+
+	default:
+		return nil, fmt.Errorf("segment %q sur une valeur scalaire", head)
+	}
+}
+
+// lookup retourne la valeur à l'emplacement tokens de doc.
+func lookup(doc any, tokens []string) (any, error) {
+	for _, t := range tokens {
+		switch node := doc.(type) {
+		case map[string]any:
+			v, ok := node[t]
+			if !ok {
+				return nil, fmt.Errorf("clé %q absente", t)
+			}
+			doc = v
+		case []any:
+			i, err := strconv.Atoi(t)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("indice %q hors limites", t)
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("segment %q sur une valeur scalaire", t)
+		}
+	}
+	return doc, nil
+}
@@ -20,6 +20,39 @@ type OrderStore interface {
 	ListResumableOrders(ctx context.Context) ([]ResumableOrder, error)
 }
 
+// PositionStore est implémentée par les OrderStore capables de persister la
+// trace GPS du livreur. Optionnelle : sans elle, la trace reste en mémoire
+// et repart de zéro après un redémarrage.
+type PositionStore interface {
+	// AppendCourierPosition ajoute une position à la trace d'une commande.
+	AppendCourierPosition(ctx context.Context, uuid string, p Position) error
+
+	// GetCourierTrail retourne la trace d'une commande, dans l'ordre chronologique.
+	GetCourierTrail(ctx context.Context, uuid string) ([]Position, error)
+}
+
+// TimelineStore est implémentée par les OrderStore capables de persister la
+// chronologie d'une commande. Optionnelle : sans elle, la chronologie repart
+// de zéro après un redémarrage.
+type TimelineStore interface {
+	// SaveTimeline remplace la chronologie d'une commande.
+	SaveTimeline(ctx context.Context, uuid string, t Timeline) error
+
+	// GetTimeline retourne la chronologie d'une commande (nil si aucune).
+	GetTimeline(ctx context.Context, uuid string) (Timeline, error)
+}
+
+// HistoryStore est implémentée par les OrderStore capables de conserver
+// l'historique des snapshots fusionnés, sous forme de patchs JSON (RFC 6902)
+// entre snapshots successifs. Optionnelle. Voir RebuildOrder.
+type HistoryStore interface {
+	// AppendPatch ajoute un patch à l'historique d'une commande.
+	AppendPatch(ctx context.Context, uuid string, e HistoryEntry) error
+
+	// GetHistory retourne l'historique d'une commande, dans l'ordre chronologique.
+	GetHistory(ctx context.Context, uuid string) ([]HistoryEntry, error)
+}
+
 // ResumableOrder contient le minimum pour relancer un worker.
 type ResumableOrder struct {
 	UUID      string
@@ -28,3 +61,19 @@ type ResumableOrder struct {
 	ClientID  string
 	CuistotID string
 }
+
+// CookieProvider fournit les cookies Uber envoyés par un Client et sait les
+// renouveler quand l'API les refuse.
+type CookieProvider interface {
+	// Get retourne le jar courant. Le Client y intègre les Set-Cookie reçus.
+	// Un jar vide signifie qu'aucun cookie n'est encore connu.
+	Get(ctx context.Context) (*CookieJar, error)
+
+	// Refresh obtient de nouveaux cookies (ex : après un 401/403 ou avant
+	// l'expiration d'un cookie critique) et retourne le nouveau jar.
+	// orderURL est la page publique de suivi, utile aux fournisseurs navigateur.
+	Refresh(ctx context.Context, orderURL string) (*CookieJar, error)
+
+	// Invalidate signale que les cookies courants ont été refusés.
+	Invalidate()
+}
@@ -0,0 +1,124 @@
+package tracker
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// ==========================================
+// Packs de langue (heuristiques et textes injectés)
+// ==========================================
+
+// LocalePack regroupe ce qui dépend de la langue des réponses Uber : les
+// mots-clés trahissant une annulation déguisée, et les textes que le tracker
+// injecte lui-même dans la commande ou dans TrackedOrder.LastText.
+type LocalePack struct {
+	Code string // code de langue, ex : "fr", "en"
+
+	// CancellationKeywords sont cherchés (en minuscules) dans le titre du
+	// callToAction d'une commande COMPLETED (voir detectPhase).
+	CancellationKeywords []string
+
+	// Textes de statut écrits à la place de ceux d'Uber quand la commande est livrée.
+	DeliveredTitle   string
+	DeliveredSummary string
+	DeliveredStatus  string
+
+	// Textes des mises à jour d'abandon du suivi.
+	OrderNotFound   string
+	TooManyFailures string
+}
+
+// LocaleFrench est le pack français, utilisé par défaut.
+var LocaleFrench = LocalePack{
+	Code:                 "fr",
+	CancellationKeywords: []string{"annul"},
+	DeliveredTitle:       "Commande Livrée",
+	DeliveredSummary:     "Bon appétit ! La commande a été livrée.",
+	DeliveredStatus:      "Livraison terminée",
+	OrderNotFound:        "Commande introuvable.",
+	TooManyFailures:      "Suivi abandonné après trop d'échecs.",
+}
+
+// LocaleEnglish est le pack anglais.
+var LocaleEnglish = LocalePack{
+	Code:                 "en",
+	CancellationKeywords: []string{"cancel"},
+	DeliveredTitle:       "Order Delivered",
+	DeliveredSummary:     "Enjoy your meal! Your order has been delivered.",
+	DeliveredStatus:      "Delivery complete",
+	OrderNotFound:        "Order not found.",
+	TooManyFailures:      "Tracking abandoned after too many failures.",
+}
+
+var (
+	localePacksMu sync.RWMutex
+	localePacks   = map[string]LocalePack{
+		LocaleFrench.Code:  LocaleFrench,
+		LocaleEnglish.Code: LocaleEnglish,
+	}
+)
+
+// RegisterLocalePack ajoute (ou remplace) un pack de langue, sélectionnable
+// ensuite par son code via LocalePackFor.
+func RegisterLocalePack(p LocalePack) {
+	localePacksMu.Lock()
+	defer localePacksMu.Unlock()
+	localePacks[strings.ToLower(p.Code)] = p
+}
+
+// LocalePackFor retourne le pack correspondant à un localeCode ("fr", "en-US",
+// "fr_CA"…) : d'abord le code exact, puis la langue seule. Un code inconnu
+// retourne le pack français.
+func LocalePackFor(code string) LocalePack {
+	code = strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+
+	localePacksMu.RLock()
+	defer localePacksMu.RUnlock()
+	if p, ok := localePacks[code]; ok {
+		return p
+	}
+	if lang, _, found := strings.Cut(code, "-"); found {
+		if p, ok := localePacks[lang]; ok {
+			return p
+		}
+	}
+	return LocaleFrench
+}
+
+// localeReportKey porte, dans le contexte d'un fetch, le localeReport où le
+// Client qui répond indique son pack de langue.
+type localeReportKey struct{}
+
+// localeReport reçoit le pack du Client à l'origine d'une réponse (nil tant
+// qu'aucun Client ne l'a indiqué, ex : FetchFn de test).
+type localeReport struct {
+	pack *LocalePack
+}
+
+// contextWithLocaleReport attache à ctx un localeReport vide, à consulter
+// après le fetch.
+func contextWithLocaleReport(ctx context.Context) (context.Context, *localeReport) {
+	r := &localeReport{}
+	return context.WithValue(ctx, localeReportKey{}, r), r
+}
+
+// reportLocale indique p comme pack de la réponse en cours, si l'appelant
+// l'attend.
+func reportLocale(ctx context.Context, p LocalePack) {
+	if r, ok := ctx.Value(localeReportKey{}).(*localeReport); ok {
+		r.pack = &p
+	}
+}
+
+// isCancellation indique si le titre d'un callToAction annonce une annulation.
+func (p LocalePack) isCancellation(title string) bool {
+	title = strings.ToLower(title)
+	for _, kw := range p.CancellationKeywords {
+		if kw != "" && strings.Contains(title, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,13 +4,28 @@ import (
 	"context"
 	"log/slog"
 	"sync"
+	"time"
 )
 
 // Manager est le chef d'orchestre du suivi des commandes.
 // Il gère le cycle de vie des workers et communique via UpdateChannel.
+//
+// Les méthodes With* configurent le Manager et le retournent pour permettre
+// le chaînage. Elles s'appliquent aux suivis lancés ensuite : à appeler avant
+// StartTracking.
 type Manager struct {
 	store         OrderStore
 	fetchFn       FetchFn
+	limiter       *RateLimiter
+	poller        *BatchPoller
+	stallAfter    time.Duration
+	newEstimator  func() ETAEstimator
+	locale        LocalePack
+	schema        *SchemaMonitor
+	redaction     PhoneRedaction
+	rules         PreservationRules
+	compiled      compiledRules // rules analysées, nil = règles par défaut
+	maxMisses     int
 	activeOrders  map[string]context.CancelFunc
 	mutex         sync.Mutex
 	UpdateChannel chan TrackedOrder
@@ -32,6 +47,104 @@ func NewManager(store OrderStore, fetchFn ...FetchFn) *Manager {
 	}
 }
 
+// WithRateLimiter fait passer tous les fetchs des workers par le limiteur l,
+// et allonge leur intervalle de polling quand le budget est saturé.
+func (m *Manager) WithRateLimiter(l *RateLimiter) *Manager {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.limiter = l
+	if m.poller != nil {
+		m.poller.setLimiter(l)
+	}
+	return m
+}
+
+// WithBatchPolling fait passer les fetchs des workers par le poller p : les
+// commandes d'une même session sont récupérées ensemble, en un minimum d'appels.
+// Le fetchFn du Manager n'est alors plus utilisé.
+func (m *Manager) WithBatchPolling(p *BatchPoller) *Manager {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.poller = p
+	if m.limiter != nil {
+		p.setLimiter(m.limiter)
+	}
+	return m
+}
+
+// WithStallThreshold définit la durée d'immobilité au-delà de laquelle un
+// livreur en route est signalé comme bloqué (défaut : 4 min).
+func (m *Manager) WithStallThreshold(d time.Duration) *Manager {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.stallAfter = d
+	return m
+}
+
+// WithETAEstimator remplace l'estimateur d'ETA par défaut : newEstimator est
+// appelé une fois par commande suivie.
+func (m *Manager) WithETAEstimator(newEstimator func() ETAEstimator) *Manager {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.newEstimator = newEstimator
+	return m
+}
+
+// WithLocalePack définit la langue des réponses interprétées par les workers
+// quand elles ne viennent pas d'un Client (défaut : français). Une réponse
+// obtenue par un Client, directement ou via un BatchPoller, est interprétée
+// avec le pack de ce client (voir Client.LocalePack) : plusieurs clients de
+// langues différentes peuvent alimenter le même Manager.
+func (m *Manager) WithLocalePack(p LocalePack) *Manager {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.locale = p
+	return m
+}
+
+// WithSchemaMonitor fait agréger par mon les écarts de format des réponses
+// reçues par les workers (voir SchemaMonitor.Stats). Sans monitor, les écarts
+// restent signalés par l'événement SchemaDrift.
+func (m *Manager) WithSchemaMonitor(mon *SchemaMonitor) *Manager {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.schema = mon
+	return m
+}
+
+// WithPhoneRedaction masque les numéros de téléphone des contacts exposés sur
+// TrackedOrder.Contacts et dans le JSON émis et persisté (FullJSONData,
+// historique) (défaut : RedactNone).
+func (m *Manager) WithPhoneRedaction(mode PhoneRedaction) *Manager {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.redaction = mode
+	return m
+}
+
+// WithPreservationRules ajoute des règles de préservation à celles par défaut
+// (une règle de même Path remplace la règle par défaut). Une règle invalide
+// est journalisée et ignorée.
+func (m *Manager) WithPreservationRules(rules ...PreservationRule) *Manager {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.rules == nil {
+		m.rules = DefaultPreservationRules()
+	}
+	m.rules = m.rules.WithRules(rules...)
+	m.compiled = m.rules.compile()
+	return m
+}
+
+// WithMissTolerance définit le nombre de polls consécutifs sans la commande
+// (liste vide, 404) avant l'abandon du suivi (défaut : 3).
+func (m *Manager) WithMissTolerance(n int) *Manager {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.maxMisses = n
+	return m
+}
+
 // StartTracking lance le suivi d'une commande. Retourne false si déjà en cours.
 func (m *Manager) StartTracking(id OrderIdentity) bool {
 	m.mutex.Lock()
@@ -44,6 +157,24 @@ func (m *Manager) StartTracking(id OrderIdentity) bool {
 	ctx, cancel := context.WithCancel(context.Background())
 	m.activeOrders[id.UUID] = cancel
 
+	fetchFn, limiter, poller := m.fetchFn, m.limiter, m.poller
+	cfg := workerConfig{
+		limiter:      limiter,
+		stallAfter:   m.stallAfter,
+		newEstimator: m.newEstimator,
+		locale:       m.locale,
+		schema:       m.schema,
+		redaction:    m.redaction,
+		rules:        m.compiled,
+		maxMisses:    m.maxMisses,
+	}
+	switch {
+	case poller != nil:
+		fetchFn = poller.Fetch // le poller applique lui-même le limiteur, par appel groupé
+	case limiter != nil:
+		fetchFn = limiter.Limit(fetchFn)
+	}
+
 	m.wg.Add(1)
 	go func() {
 		defer m.wg.Done()
@@ -51,9 +182,12 @@ func (m *Manager) StartTracking(id OrderIdentity) bool {
 			m.mutex.Lock()
 			delete(m.activeOrders, id.UUID)
 			m.mutex.Unlock()
+			if poller != nil {
+				poller.Forget(id.UUID)
+			}
 			cancel()
 		}()
-		StartOrderWorker(ctx, m.store, id, m.UpdateChannel, m.fetchFn)
+		runOrderWorker(ctx, m.store, id, m.UpdateChannel, fetchFn, cfg)
 	}()
 
 	return true
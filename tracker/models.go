@@ -32,6 +32,26 @@ type TrackedOrder struct {
 	LastText     string
 	MessageID    string
 	ETAMinutes   int // Temps restant en minutes (extrait de backgroundFeedCards), -1 = inconnu
+
+	// EstimatedETA est l'estimation du tracker, renseignée quand l'ETA d'Uber
+	// est absent (ETAMinutes = -1) ou instable.
+	EstimatedETA *ETAEstimate
+
+	// Contacts sont le livreur et le restaurant, numéros masqués selon
+	// Manager.WithPhoneRedaction (comme dans FullJSONData).
+	Contacts OrderContacts
+
+	// Motion décrit le déplacement du livreur (distance restante, vitesse, blocage).
+	Motion CourierMotion
+
+	// Timeline date les jalons de la commande (préparation, enlèvement, livraison…).
+	Timeline Timeline
+
+	// CourierTrail est la trace horodatée du livreur depuis le début du suivi.
+	CourierTrail []Position
+
+	// Events liste ce qui a changé depuis la mise à jour précédente (non persisté).
+	Events []Event
 }
 
 // ==========================================
@@ -47,6 +67,7 @@ type Data struct {
 }
 
 type Order struct {
+	UUID                string               `json:"uuid,omitempty"`
 	Contacts            []Contact            `json:"contacts"`
 	ActiveOrderOverview ActiveOrderOverview  `json:"activeOrderOverview"`
 	FeedCards           []FeedCard           `json:"feedCards"`
@@ -78,6 +99,8 @@ type OrderInfo struct {
 type Contact struct {
 	Title                string `json:"title"`
 	FormattedPhoneNumber string `json:"formattedPhoneNumber"`
+	// Type vaut "COURIER" ou "RESTAURANT" quand Uber le précise (voir Contact.Role).
+	Type string `json:"type,omitempty"`
 }
 
 type ActiveOrderOverview struct {
@@ -90,6 +113,18 @@ type Item struct {
 	Title    string `json:"title"`
 	Quantity int    `json:"quantity"`
 	Subtitle string `json:"subtitle"`
+
+	// Prix unitaire formaté par Uber (ex : "8,50 €"), hors options. Voir ExtractCart.
+	Price               string         `json:"price,omitempty"`
+	Customizations      []ItemModifier `json:"customizations,omitempty"`
+	SpecialInstructions string         `json:"specialInstructions,omitempty"`
+}
+
+// ItemModifier est une option d'un article (supplément, sauce…), avec son
+// surcoût unitaire formaté (vide si gratuit).
+type ItemModifier struct {
+	Title string `json:"title"`
+	Price string `json:"price,omitempty"`
 }
 
 // FeedCard utilise des pointeurs pour gérer la diversité des objets dans le tableau
@@ -100,10 +135,23 @@ type FeedCard struct {
 	Courier      []CourierInfo `json:"courier,omitempty"`
 	Delivery     *DeliveryInfo `json:"delivery,omitempty"`
 
-	// Structure anonyme pour le total
-	OrderSummary struct {
-		Total string `json:"total"`
-	} `json:"orderSummary,omitempty"`
+	OrderSummary OrderSummary `json:"orderSummary,omitempty"`
+}
+
+// OrderSummary est le récapitulatif de paiement. Les montants sont formatés
+// par Uber (ex : "24,90 €") ; ExtractCart les convertit en Money.
+type OrderSummary struct {
+	Total        string `json:"total"`
+	Subtotal     string `json:"subtotal,omitempty"`
+	Fees         []Fee  `json:"fees,omitempty"`
+	Tip          string `json:"tip,omitempty"`
+	CurrencyCode string `json:"currencyCode,omitempty"` // ISO 4217, ex : "EUR"
+}
+
+// Fee est une ligne de frais (livraison, service, petite commande…).
+type Fee struct {
+	Title  string `json:"title"`
+	Amount string `json:"amount"`
 }
 
 // CallToAction représente un message final (annulation, erreur, etc.)
@@ -138,6 +186,12 @@ type SummaryContent struct {
 
 type CourierInfo struct {
 	PinInfo PinInfo `json:"pinVerificationInfo"`
+
+	// Profil du livreur, quand Uber l'expose (voir ExtractContacts).
+	Name        string  `json:"name,omitempty"`
+	VehicleType string  `json:"vehicleType,omitempty"`
+	Rating      float64 `json:"rating,omitempty"`
+	PictureURL  string  `json:"pictureUrl,omitempty"`
 }
 
 type PinInfo struct {
@@ -0,0 +1,116 @@
+package tracker
+
+import (
+	"math"
+	"time"
+)
+
+// ==========================================
+// Distance, vitesse et immobilisation du livreur
+// ==========================================
+
+const (
+	// earthRadiusMeters est le rayon terrestre moyen utilisé par la formule de haversine.
+	earthRadiusMeters = 6371000.0
+
+	// speedSmoothing est le poids de la dernière mesure dans la vitesse lissée
+	// (moyenne mobile exponentielle).
+	speedSmoothing = 0.3
+
+	// stallRadiusMeters absorbe l'imprécision GPS : un livreur qui reste dans
+	// ce rayon est considéré comme immobile.
+	stallRadiusMeters = 25.0
+)
+
+// DistanceTo retourne la distance orthodromique (haversine) entre c et o, en mètres.
+func (c Coordinates) DistanceTo(o Coordinates) float64 {
+	lat1, lat2 := c.Latitude*math.Pi/180, o.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (o.Longitude - c.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// CourierMotion décrit le déplacement du livreur au dernier poll.
+type CourierMotion struct {
+	DistanceRemaining float64       // mètres jusqu'à la destination, -1 = inconnue
+	Speed             float64       // m/s depuis le poll précédent
+	SmoothedSpeed     float64       // m/s, lissée sur les derniers polls
+	Stalled           bool          // immobile depuis au moins le seuil configuré, en route
+	StallStarted      bool          // Stalled vient de passer à vrai (signalé une fois par arrêt)
+	StillFor          time.Duration // durée d'immobilité du livreur
+}
+
+// isEnRoute indique si le livreur apporte la commande au client : sa position
+// est connue et la progression de la feedCard montre la commande enlevée mais
+// pas encore livrée. Les réponses ne portant que la phase ACTIVE, EN_ROUTE
+// n'est pas attendue (voir OrderPhase).
+func isEnRoute(phase OrderPhase, progress, total int, courier *Coordinates) bool {
+	return courier != nil && !phase.IsTerminal() &&
+		pickedUpByProgress(progress, total) && progress < total
+}
+
+// MotionTracker suit le déplacement du livreur d'une commande entre les
+// polls. Le worker en tient un par commande ; un appelant de Reconcile peut
+// l'utiliser de la même façon. Non sûr pour un usage concurrent.
+type MotionTracker struct {
+	stallAfter time.Duration
+
+	prev       Coordinates // position au poll précédent (vitesse)
+	prevAt     time.Time
+	anchor     Coordinates // position où l'immobilité a commencé
+	stillSince time.Time
+	smoothed   float64
+	measured   bool // smoothed contient au moins une mesure
+	stalled    bool // dernier état signalé (voir CourierMotion.StallStarted)
+}
+
+// NewMotionTracker crée un suivi de déplacement. Un livreur en route immobile
+// depuis stallAfter est signalé comme bloqué (≤ 0 = défaut, 4 min).
+func NewMotionTracker(stallAfter time.Duration) *MotionTracker {
+	if stallAfter <= 0 {
+		stallAfter = defaultStallThreshold
+	}
+	return &MotionTracker{stallAfter: stallAfter}
+}
+
+// Observe enregistre la position courier (nil si inconnue) relevée à now et
+// retourne le déplacement calculé. enRoute indique si le livreur apporte la
+// commande (ReconcileResult.EnRoute) : seul un livreur en route est signalé
+// comme bloqué.
+func (m *MotionTracker) Observe(courier, destination *Coordinates, enRoute bool, now time.Time) CourierMotion {
+	motion := CourierMotion{DistanceRemaining: -1}
+	if courier == nil {
+		m.stalled = false
+		return motion
+	}
+	if destination != nil {
+		motion.DistanceRemaining = courier.DistanceTo(*destination)
+	}
+
+	if m.prevAt.IsZero() {
+		m.anchor, m.stillSince = *courier, now
+	} else {
+		if dt := now.Sub(m.prevAt).Seconds(); dt > 0 {
+			motion.Speed = m.prev.DistanceTo(*courier) / dt
+			if m.measured {
+				m.smoothed = speedSmoothing*motion.Speed + (1-speedSmoothing)*m.smoothed
+			} else {
+				m.smoothed, m.measured = motion.Speed, true
+			}
+		}
+		if m.anchor.DistanceTo(*courier) > stallRadiusMeters {
+			m.anchor, m.stillSince = *courier, now
+		}
+	}
+	m.prev, m.prevAt = *courier, now
+
+	motion.SmoothedSpeed = m.smoothed
+	motion.StillFor = now.Sub(m.stillSince)
+	motion.Stalled = enRoute && motion.StillFor >= m.stallAfter
+	motion.StallStarted = motion.Stalled && !m.stalled
+	m.stalled = motion.Stalled
+	return motion
+}
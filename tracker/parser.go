@@ -1,12 +1,11 @@
 package tracker
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 )
 
-const phaseCompleted = "COMPLETED"
-
 // ExtractETAFromOrder cherche l'entité de type "LABEL" dans les BackgroundFeedCards
 // et retourne l'ETA (title) en minutes. Retourne -1 si introuvable.
 func ExtractETAFromOrder(order Order) int {
@@ -22,75 +21,131 @@ func ExtractETAFromOrder(order Order) int {
 	return -1
 }
 
+// ExtractCourierPosition retourne la position du livreur (entité "COURIER").
+func ExtractCourierPosition(order Order) (Coordinates, bool) {
+	return extractMapPoint(order, "COURIER")
+}
+
+// ExtractRestaurantPosition retourne la position du restaurant (entité "RESTAURANT").
+func ExtractRestaurantPosition(order Order) (Coordinates, bool) {
+	return extractMapPoint(order, "RESTAURANT")
+}
+
+// ExtractDestination retourne la position du client (entité "EATER").
+func ExtractDestination(order Order) (Coordinates, bool) {
+	return extractMapPoint(order, "EATER")
+}
+
+// extractMapPoint retourne les coordonnées de la première entité de type
+// entityType. Une entité en (0, 0) est considérée comme absente.
+func extractMapPoint(order Order, entityType string) (Coordinates, bool) {
+	for _, card := range order.BackgroundFeedCards {
+		for _, entity := range card.MapEntity {
+			if entity.Type != entityType {
+				continue
+			}
+			c := Coordinates{Latitude: entity.Latitude, Longitude: entity.Longitude}
+			if c.IsZero() {
+				continue
+			}
+			return c, true
+		}
+	}
+	return Coordinates{}, false
+}
+
 // ==========================================
 // Données préservées entre deux polls
 // ==========================================
 
-// preservedInfo contient les informations qui disparaissent de l'API au fil
-// du temps (total, adresse, PIN) et doivent être conservées entre les polls.
-type preservedInfo struct {
-	total   string
+// orderDetails regroupe les informations d'un Order que l'API finit par
+// retirer (récapitulatif de paiement, adresse, PIN, profil du livreur). Elles
+// sont conservées entre les polls par les règles de préservation.
+type orderDetails struct {
+	summary OrderSummary
 	address string
 	pin     string
+	courier CourierInfo // profil du livreur, sans le PIN
 }
 
-// extractPreservedInfo parcourt les FeedCards d'un Order et extrait les
-// informations susceptibles de disparaître de l'API plus tard.
-func extractPreservedInfo(o Order) preservedInfo {
-	var p preservedInfo
+// detailsOf lit ces informations dans les FeedCards d'un Order.
+func detailsOf(o Order) orderDetails {
+	var d orderDetails
 	for _, card := range o.FeedCards {
-		if card.OrderSummary.Total != "" {
-			p.total = card.OrderSummary.Total
-		}
+		d.summary = mergeSummary(d.summary, card.OrderSummary)
 		if card.Delivery != nil && card.Delivery.Address != "" {
-			p.address = card.Delivery.Address
+			d.address = card.Delivery.Address
 		}
 		for _, c := range card.Courier {
 			if c.PinInfo.Pin != "" {
-				p.pin = c.PinInfo.Pin
+				d.pin = c.PinInfo.Pin
 			}
+			c.PinInfo = PinInfo{}
+			d.courier = mergeCourier(d.courier, c)
 		}
 	}
-	return p
+	return d
 }
 
-// restorePreservedInfo réinjecte les données perdues dans le premier FeedCard
-// si elles sont absentes de l'Order actuel.
-func restorePreservedInfo(o *Order, p preservedInfo) {
-	if len(o.FeedCards) == 0 {
-		return
+// mergeCourier retourne kept, dont chaque champ du profil renseigné dans fresh
+// est remplacé (le PIN est traité à part).
+func mergeCourier(kept, fresh CourierInfo) CourierInfo {
+	if fresh.Name != "" {
+		kept.Name = fresh.Name
+	}
+	if fresh.VehicleType != "" {
+		kept.VehicleType = fresh.VehicleType
 	}
-	fc := &o.FeedCards[0]
+	if fresh.Rating != 0 {
+		kept.Rating = fresh.Rating
+	}
+	if fresh.PictureURL != "" {
+		kept.PictureURL = fresh.PictureURL
+	}
+	return kept
+}
 
-	if fc.OrderSummary.Total == "" && p.total != "" {
-		fc.OrderSummary.Total = p.total
+// mergeSummary retourne kept, dont chaque champ renseigné dans fresh est remplacé.
+func mergeSummary(kept, fresh OrderSummary) OrderSummary {
+	if fresh.Total != "" {
+		kept.Total = fresh.Total
 	}
-	if p.address != "" {
-		if fc.Delivery == nil {
-			fc.Delivery = &DeliveryInfo{}
-		}
-		if fc.Delivery.Address == "" {
-			fc.Delivery.Address = p.address
-		}
+	if fresh.Subtotal != "" {
+		kept.Subtotal = fresh.Subtotal
 	}
-	if p.pin != "" {
-		if len(fc.Courier) == 0 {
-			fc.Courier = []CourierInfo{{}}
-		}
-		if fc.Courier[0].PinInfo.Pin == "" {
-			fc.Courier[0].PinInfo.Pin = p.pin
+	if len(fresh.Fees) > 0 {
+		kept.Fees = fresh.Fees
+	}
+	if fresh.Tip != "" {
+		kept.Tip = fresh.Tip
+	}
+	if fresh.CurrencyCode != "" {
+		kept.CurrencyCode = fresh.CurrencyCode
+	}
+	return kept
+}
+
+// cloneFeedCards copie les FeedCards et leur statut, que la fusion modifie en place.
+func cloneFeedCards(cards []FeedCard) []FeedCard {
+	cards = slices.Clone(cards)
+	for i, c := range cards {
+		if c.Status != nil {
+			status := *c.Status
+			cards[i].Status = &status
 		}
 	}
+	return cards
 }
 
 // detectPhase retourne la phase effective de la commande, en corrigeant le
-// cas d'une annulation déguisée (Uber renvoie COMPLETED + callToAction "annulée").
-func detectPhase(newOrder Order) string {
-	phase := newOrder.OrderStatus.OrderPhase
-	if phase == phaseCompleted {
+// cas d'une annulation déguisée (Uber renvoie COMPLETED + callToAction "annulée",
+// reconnu grâce aux mots-clés du pack de langue).
+func detectPhase(newOrder Order, locale LocalePack) OrderPhase {
+	phase := ParsePhase(newOrder.OrderStatus.OrderPhase)
+	if phase == PhaseCompleted {
 		for _, card := range newOrder.FeedCards {
-			if card.CallToAction != nil && strings.Contains(strings.ToLower(card.CallToAction.Title), "annul") {
-				return "CANCELLED"
+			if card.CallToAction != nil && locale.isCancellation(card.CallToAction.Title) {
+				return PhaseCancelled
 			}
 		}
 	}
@@ -101,24 +156,46 @@ func detectPhase(newOrder Order) string {
 // Fusion publique
 // ==========================================
 
+// MergeOption configure MergeOrderData.
+type MergeOption func(*mergeConfig)
+
+type mergeConfig struct {
+	locale LocalePack
+	rules  compiledRules
+}
+
+// WithMergeLocale choisit le pack de langue des réponses : mots-clés
+// d'annulation et textes injectés à la livraison (défaut : LocaleFrench).
+func WithMergeLocale(locale LocalePack) MergeOption {
+	return func(c *mergeConfig) { c.locale = locale }
+}
+
+// WithMergeRules remplace les règles de préservation par défaut (voir
+// DefaultPreservationRules). Les règles sont analysées une fois, à l'appel de
+// WithMergeRules ; une règle invalide est journalisée et ignorée.
+func WithMergeRules(rules PreservationRules) MergeOption {
+	return withCompiledRules(rules.compile())
+}
+
+// withCompiledRules applique des règles déjà analysées.
+func withCompiledRules(rules compiledRules) MergeOption {
+	return func(c *mergeConfig) { c.rules = rules }
+}
+
 // MergeOrderData fusionne les données anciennes (masterOrder) et nouvelles (newOrder).
 // Retourne l'Order fusionné et la phase détectée (avec détection d'annulation déguisée).
-func MergeOrderData(masterOrder, newOrder Order, hasOldData bool) (Order, string) {
-	// 1. Préservation des données précieuses
-	var kept preservedInfo
-	if hasOldData {
-		kept = extractPreservedInfo(masterOrder)
+// Les données perdues par Uber sont restaurées selon les règles de préservation.
+func MergeOrderData(masterOrder, newOrder Order, hasOldData bool, opts ...MergeOption) (Order, OrderPhase) {
+	cfg := mergeConfig{locale: LocaleFrench, rules: defaultCompiledRules}
+	for _, opt := range opts {
+		opt(&cfg)
 	}
-	// Les données les plus récentes priment
-	fresh := extractPreservedInfo(newOrder)
-	if fresh.total != "" {
-		kept.total = fresh.total
-	}
-	if fresh.address != "" {
-		kept.address = fresh.address
-	}
-	if fresh.pin != "" {
-		kept.pin = fresh.pin
+	locale := cfg.locale
+
+	// 1. Copie de l'ancien snapshot, source des règles de préservation
+	previous := masterOrder
+	if hasOldData {
+		previous.FeedCards = cloneFeedCards(masterOrder.FeedCards)
 	}
 
 	// 2. Fusion des champs top-level
@@ -128,17 +205,21 @@ func MergeOrderData(masterOrder, newOrder Order, hasOldData bool) (Order, string
 	if len(newOrder.Contacts) > 0 {
 		masterOrder.Contacts = newOrder.Contacts
 	}
+	// Toujours prendre les backgroundFeedCards les plus récents (ETA temps réel)
+	if len(newOrder.BackgroundFeedCards) > 0 {
+		masterOrder.BackgroundFeedCards = newOrder.BackgroundFeedCards
+	}
 
 	// 3. Détection de la phase réelle
-	newPhase := detectPhase(newOrder)
+	newPhase := detectPhase(newOrder, locale)
 
 	// 4. Mise à jour des feedCards
-	if newPhase == phaseCompleted {
+	if newPhase == PhaseCompleted {
 		for i, card := range masterOrder.FeedCards {
 			if card.Status != nil {
-				masterOrder.FeedCards[i].Status.Title = "Commande Livrée"
-				masterOrder.FeedCards[i].Status.TitleSummary.Summary.Text = "Bon appétit ! La commande a été livrée."
-				masterOrder.FeedCards[i].Status.StatusSummary.Text = "Livraison terminée"
+				masterOrder.FeedCards[i].Status.Title = locale.DeliveredTitle
+				masterOrder.FeedCards[i].Status.TitleSummary.Summary.Text = locale.DeliveredSummary
+				masterOrder.FeedCards[i].Status.StatusSummary.Text = locale.DeliveredStatus
 				masterOrder.FeedCards[i].Status.CurrentProgress = 5
 				masterOrder.FeedCards[i].Status.TotalProgress = 5
 				break
@@ -149,10 +230,11 @@ func MergeOrderData(masterOrder, newOrder Order, hasOldData bool) (Order, string
 			masterOrder.FeedCards = newOrder.FeedCards
 		}
 	}
-	masterOrder.OrderStatus.OrderPhase = newPhase
+	masterOrder.OrderStatus.OrderPhase = string(newPhase)
 
 	// 5. Restauration des données perdues
-	restorePreservedInfo(&masterOrder, kept)
+	src := &mergeSources{master: previous, fresh: newOrder, hasOldData: hasOldData}
+	masterOrder = applyPreservationRules(masterOrder, src, newPhase, cfg.rules)
 
 	return masterOrder, newPhase
 }
@@ -0,0 +1,112 @@
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// ==========================================
+// Phases de commande (machine à états)
+// ==========================================
+
+// OrderPhase est la phase d'une commande, telle que retournée par Uber
+// (Order.OrderStatus.OrderPhase) ou attribuée par le tracker (PhaseFailed).
+// Une phase inconnue conserve sa valeur brute.
+type OrderPhase string
+
+// Phases connues.
+//
+// Les réponses capturées ne portent que ACTIVE pendant toute la commande :
+// l'avancement se lit dans la progression de la feedCard et la présence du
+// livreur (voir milestonesReached). PREPARING, COURIER_ASSIGNED et
+// EN_ROUTE sont hypothétiques : jamais observées, elles sont acceptées si Uber
+// les envoie, mais aucune fonctionnalité ne doit en dépendre.
+const (
+	PhaseActive OrderPhase = "ACTIVE"
+	// PhasePreparing est hypothétique (jamais observée).
+	PhasePreparing OrderPhase = "PREPARING"
+	// PhaseCourierAssigned est hypothétique (jamais observée).
+	PhaseCourierAssigned OrderPhase = "COURIER_ASSIGNED"
+	// PhaseEnRoute est hypothétique (jamais observée).
+	PhaseEnRoute   OrderPhase = "EN_ROUTE"
+	PhaseDelivered OrderPhase = "DELIVERED"
+	PhaseCompleted OrderPhase = "COMPLETED"
+	PhaseCancelled OrderPhase = "CANCELLED"
+	// PhaseFailed est attribuée par le worker quand il abandonne le suivi.
+	PhaseFailed OrderPhase = "FAILED"
+)
+
+// ErrInvalidTransition est retournée par ValidateTransition pour un
+// changement de phase impossible (ex : COMPLETED → PREPARING).
+var ErrInvalidTransition = errors.New("transition de phase impossible")
+
+// phaseTransitions liste, pour chaque phase connue, les phases suivantes
+// possibles (en plus d'elle-même et de PhaseFailed). ACTIVE est la phase
+// générique d'Uber : elle peut précéder ou suivre toute phase en cours.
+var phaseTransitions = map[OrderPhase][]OrderPhase{
+	PhaseActive:          {PhasePreparing, PhaseCourierAssigned, PhaseEnRoute, PhaseDelivered, PhaseCompleted, PhaseCancelled},
+	PhasePreparing:       {PhaseActive, PhaseCourierAssigned, PhaseEnRoute, PhaseDelivered, PhaseCompleted, PhaseCancelled},
+	PhaseCourierAssigned: {PhaseActive, PhasePreparing, PhaseEnRoute, PhaseDelivered, PhaseCompleted, PhaseCancelled},
+	PhaseEnRoute:         {PhaseActive, PhaseDelivered, PhaseCompleted, PhaseCancelled},
+	// Uber confirme une livraison en plusieurs temps, et déguise parfois une
+	// annulation en COMPLETED (voir detectPhase).
+	PhaseDelivered: {PhaseCompleted, PhaseCancelled},
+	PhaseCompleted: {PhaseDelivered, PhaseCancelled},
+	PhaseCancelled: {},
+	PhaseFailed:    {},
+}
+
+// unknownPhasesSeen retient les phases inconnues déjà journalisées : une
+// commande bloquée dans une telle phase la renvoie à chaque poll.
+var unknownPhasesSeen sync.Map
+
+// ParsePhase convertit une phase brute. Une phase inconnue est journalisée
+// (une fois par valeur) et conservée telle quelle (Known retourne false).
+func ParsePhase(raw string) OrderPhase {
+	p := OrderPhase(raw)
+	if raw != "" && !p.Known() {
+		if _, seen := unknownPhasesSeen.LoadOrStore(raw, struct{}{}); !seen {
+			slog.Warn("phase de commande inconnue", "phase", raw)
+		}
+	}
+	return p
+}
+
+// Known indique si p fait partie des phases connues.
+func (p OrderPhase) Known() bool {
+	_, ok := phaseTransitions[p]
+	return ok
+}
+
+// IsTerminal indique si la commande est terminée : le suivi s'arrête.
+func (p OrderPhase) IsTerminal() bool {
+	switch p {
+	case PhaseDelivered, PhaseCompleted, PhaseCancelled, PhaseFailed:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo indique si la commande peut passer de p à next. Les
+// transitions depuis ou vers une phase inconnue (ou vide) sont acceptées.
+func (p OrderPhase) CanTransitionTo(next OrderPhase) bool {
+	if p == next || next == PhaseFailed || !p.Known() || !next.Known() {
+		return true
+	}
+	for _, allowed := range phaseTransitions[p] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition retourne ErrInvalidTransition si from → to est impossible.
+func ValidateTransition(from, to OrderPhase) error {
+	if from.CanTransitionTo(to) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, from, to)
+}
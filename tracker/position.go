@@ -0,0 +1,86 @@
+package tracker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ==========================================
+// Positions et trajet du livreur
+// ==========================================
+
+// maxTrailPoints borne la trace conservée par commande (≈ 1 point par poll).
+const maxTrailPoints = 1000
+
+// Coordinates est un point GPS (degrés décimaux, WGS 84).
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// IsZero indique si c est le point (0, 0), utilisé par Uber pour « inconnu ».
+func (c Coordinates) IsZero() bool {
+	return c.Latitude == 0 && c.Longitude == 0
+}
+
+// Position est une position horodatée du livreur, annotée avec la phase et
+// l'ETA de la commande au moment du relevé.
+type Position struct {
+	Coordinates
+	At    time.Time  `json:"at"`
+	Phase OrderPhase `json:"phase,omitempty"`
+	ETA   int        `json:"eta"` // minutes, -1 = inconnu
+}
+
+// courierTrail accumule les positions du livreur d'une commande et les
+// persiste si le store implémente PositionStore.
+type courierTrail struct {
+	uuid   string
+	store  PositionStore // nil = trace en mémoire seulement
+	points []Position
+}
+
+// newCourierTrail crée la trace d'une commande, en reprenant la trace
+// persistée (reprise après redémarrage).
+func newCourierTrail(ctx context.Context, store OrderStore, uuid string) *courierTrail {
+	t := &courierTrail{uuid: uuid}
+	ps, ok := store.(PositionStore)
+	if !ok {
+		return t
+	}
+	t.store = ps
+	points, err := ps.GetCourierTrail(ctx, uuid)
+	if err != nil {
+		slog.Warn("lecture de la trace du livreur échouée", "uuid", SafeTruncate(uuid, 8), "error", err)
+		return t
+	}
+	t.points = points
+	return t
+}
+
+// record ajoute p si sa position diffère de la dernière connue.
+// Retourne true si la trace a changé.
+func (t *courierTrail) record(ctx context.Context, p Position) bool {
+	if n := len(t.points); n > 0 && t.points[n-1].Coordinates == p.Coordinates {
+		return false
+	}
+	t.points = append(t.points, p)
+	if len(t.points) > maxTrailPoints {
+		t.points = t.points[len(t.points)-maxTrailPoints:]
+	}
+	if t.store != nil {
+		if err := t.store.AppendCourierPosition(ctx, t.uuid, p); err != nil {
+			slog.Warn("persistance de la position du livreur échouée", "uuid", SafeTruncate(t.uuid, 8), "error", err)
+		}
+	}
+	return true
+}
+
+// snapshot retourne une copie de la trace (nil si vide).
+func (t *courierTrail) snapshot() []Position {
+	if len(t.points) == 0 {
+		return nil
+	}
+	return append([]Position(nil), t.points...)
+}
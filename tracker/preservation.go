@@ -0,0 +1,621 @@
+package tracker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ==========================================
+// Règles de préservation des données
+// ==========================================
+
+// ErrInvalidRule est retournée pour une règle de préservation mal formée.
+var ErrInvalidRule = errors.New("règle de préservation invalide")
+
+// PreservationStrategy choisit la valeur conservée quand l'ancien snapshot et
+// la nouvelle réponse en portent chacun une.
+type PreservationStrategy int
+
+const (
+	// LatestWins conserve la valeur la plus récente (défaut).
+	LatestWins PreservationStrategy = iota
+	// FirstWins conserve la première valeur observée.
+	FirstWins
+)
+
+// PreservationRule déclare une donnée à conserver quand Uber cesse de
+// l'envoyer. MergeOrderData la réinjecte dans l'Order fusionné.
+//
+// Path est un JSON Pointer (RFC 6901) dans l'Order, avec les noms JSON des
+// champs. Dans un tableau, un segment peut être un indice, « * » (n'importe
+// quel élément) ou « [clé=valeur] » (les éléments dont le champ clé vaut
+// valeur). La valeur lue est la première non vide ; elle est restaurée dans
+// l'élément qui la portait, à défaut le premier élément correspondant, créé
+// si besoin ; une carte (feedCards, backgroundFeedCards) n'est jamais créée,
+// la valeur n'est restaurée que dans une carte présente dans la réponse.
+// Seuls les champs modélisés par Order peuvent être conservés.
+//
+// Un segment « [clé=*] » (un seul par chemin) rapproche chaque élément de
+// l'Order fusionné de l'élément de même clé de l'ancien snapshot et de la
+// réponse, ex : le prix de chaque article, par titre. Les contacts portent un
+// champ virtuel « _role » (COURIER ou RESTAURANT, voir ExtractContacts),
+// utilisable dans un filtre.
+//
+// Une valeur vide (absente, null, "", 0, false, tableau ou objet vide) compte
+// comme absente. Avec LatestWins, une valeur déjà présente dans l'Order
+// fusionné (issu de la réponse) est conservée telle quelle.
+type PreservationRule struct {
+	Path     string
+	Strategy PreservationStrategy
+	// ClearOn liste les phases où la valeur conservée est oubliée : elle n'est
+	// plus restaurée, et retirée de l'Order fusionné si la réponse ne la porte pas.
+	ClearOn []OrderPhase
+}
+
+// contactRoleKey est le champ virtuel ajouté aux contacts pour les règles.
+const contactRoleKey = "_role"
+
+// defaultPreservationRules conserve les articles (et leurs détails, par
+// titre), les contacts du livreur et du restaurant, le récapitulatif de
+// paiement, l'adresse, le PIN et le profil du livreur.
+var defaultPreservationRules = PreservationRules{
+	{Path: "/activeOrderOverview/items"},
+	{Path: "/activeOrderOverview/items/[title=*]/price"},
+	{Path: "/activeOrderOverview/items/[title=*]/customizations"},
+	{Path: "/activeOrderOverview/items/[title=*]/specialInstructions"},
+	{Path: "/contacts/[_role=COURIER]"},
+	{Path: "/contacts/[_role=RESTAURANT]"},
+	{Path: "/feedCards/*/orderSummary/total"},
+	{Path: "/feedCards/*/orderSummary/subtotal"},
+	{Path: "/feedCards/*/orderSummary/fees"},
+	{Path: "/feedCards/*/orderSummary/tip"},
+	{Path: "/feedCards/*/orderSummary/currencyCode"},
+	{Path: "/feedCards/*/delivery/formattedAddress"},
+	{Path: "/feedCards/*/courier/*/pinVerificationInfo/pin"},
+	{Path: "/feedCards/*/courier/*/name"},
+	{Path: "/feedCards/*/courier/*/vehicleType"},
+	{Path: "/feedCards/*/courier/*/rating"},
+	{Path: "/feedCards/*/courier/*/pictureUrl"},
+}
+
+// defaultCompiledRules sont les règles par défaut, analysées une fois.
+var defaultCompiledRules = defaultPreservationRules.compile()
+
+// PreservationRules est un jeu de règles, appliquées dans l'ordre.
+type PreservationRules []PreservationRule
+
+// DefaultPreservationRules retourne une copie des règles appliquées par
+// MergeOrderData.
+func DefaultPreservationRules() PreservationRules {
+	return slices.Clone(defaultPreservationRules)
+}
+
+// WithRules retourne base complétée par extra ; une règle de même Path
+// remplace celle de base. Exemple, pour conserver la position du restaurant :
+//
+//	DefaultPreservationRules().WithRules(PreservationRule{
+//		Path: "/backgroundFeedCards/*/mapEntity/[type=RESTAURANT]",
+//	})
+func (base PreservationRules) WithRules(extra ...PreservationRule) PreservationRules {
+	rules := slices.Clone(base)
+	for _, r := range extra {
+		if i := slices.IndexFunc(rules, func(k PreservationRule) bool { return k.Path == r.Path }); i >= 0 {
+			rules[i] = r
+		} else {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// Validate vérifie la syntaxe de Path.
+func (r PreservationRule) Validate() error {
+	_, err := parseRulePath(r.Path)
+	return err
+}
+
+// compiledRule est une règle dont le chemin a été analysé.
+type compiledRule struct {
+	PreservationRule
+	segs    []pathSegment
+	pairing int // indice du segment « [clé=*] », -1 si aucun
+}
+
+// compiledRules est un jeu de règles prêt à être appliqué.
+type compiledRules []compiledRule
+
+// compile analyse les chemins de rules. Une règle invalide est journalisée et
+// ignorée.
+func (rules PreservationRules) compile() compiledRules {
+	compiled := make(compiledRules, 0, len(rules))
+	for _, r := range rules {
+		segs, err := parseRulePath(r.Path)
+		if err != nil {
+			slog.Error("règle de préservation ignorée", "path", r.Path, "error", err)
+			continue
+		}
+		compiled = append(compiled, compiledRule{
+			PreservationRule: r,
+			segs:             segs,
+			pairing:          slices.IndexFunc(segs, func(s pathSegment) bool { return s.pairing }),
+		})
+	}
+	return compiled
+}
+
+// pathSegment est un segment de PreservationRule.Path.
+type pathSegment struct {
+	key         string // clé d'objet ou indice de tableau
+	wildcard    bool   // « * »
+	filter      bool   // « [filterKey=filterValue] »
+	pairing     bool   // « [filterKey=*] » : élément homologue (voir expand)
+	filterKey   string
+	filterValue string
+}
+
+// parseRulePath découpe le chemin d'une règle en segments.
+func parseRulePath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%w: chemin vide", ErrInvalidRule)
+	}
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRule, err)
+	}
+	segs := make([]pathSegment, len(tokens))
+	pairings := 0
+	for i, t := range tokens {
+		switch {
+		case t == "*":
+			segs[i] = pathSegment{wildcard: true}
+		case strings.HasPrefix(t, "[") && strings.HasSuffix(t, "]"):
+			k, v, ok := strings.Cut(t[1:len(t)-1], "=")
+			if !ok || k == "" {
+				return nil, fmt.Errorf("%w: filtre %q (attendu [clé=valeur])", ErrInvalidRule, t)
+			}
+			if v == "*" {
+				segs[i] = pathSegment{pairing: true, filterKey: k}
+				pairings++
+				continue
+			}
+			segs[i] = pathSegment{filter: true, filterKey: k, filterValue: v}
+		default:
+			segs[i] = pathSegment{key: t}
+		}
+	}
+	if pairings > 1 {
+		return nil, fmt.Errorf("%w: un seul segment [clé=*] par chemin", ErrInvalidRule)
+	}
+	return segs, nil
+}
+
+// matches indique si l'élément el, d'indice i, correspond au segment.
+func (s pathSegment) matches(el any, i int) bool {
+	switch {
+	case s.wildcard:
+		return true
+	case s.filter:
+		obj, ok := el.(map[string]any)
+		return ok && obj[s.filterKey] != nil && fmt.Sprint(obj[s.filterKey]) == s.filterValue
+	case s.pairing:
+		return false // remplacé par un filtre avant application (voir expand)
+	default:
+		idx, err := strconv.Atoi(s.key)
+		return err == nil && idx == i
+	}
+}
+
+// isEmptyJSON indique si une valeur décodée compte comme absente.
+func isEmptyJSON(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return x == ""
+	case bool:
+		return !x
+	case json.Number:
+		f, err := x.Float64()
+		return err == nil && f == 0
+	case []any:
+		return len(x) == 0
+	case map[string]any:
+		for _, child := range x {
+			if !isEmptyJSON(child) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// findValue retourne la première valeur non vide de node à l'emplacement segs.
+func findValue(node any, segs []pathSegment) (any, bool) {
+	if len(segs) == 0 {
+		return node, !isEmptyJSON(node)
+	}
+	s, rest := segs[0], segs[1:]
+	switch n := node.(type) {
+	case map[string]any:
+		if s.wildcard || s.filter {
+			return nil, false
+		}
+		return findValue(n[s.key], rest)
+	case []any:
+		for i, el := range n {
+			if !s.matches(el, i) {
+				continue
+			}
+			if v, ok := findValue(el, rest); ok {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// cardArrays sont les tableaux de cartes de l'Order : leurs éléments portent
+// un statut que setValue ne sait pas créer.
+var cardArrays = map[string]bool{"feedCards": true, "backgroundFeedCards": true}
+
+// errNoElement signale que setValue aurait dû créer une carte.
+var errNoElement = errors.New("aucune carte où restaurer la valeur")
+
+// setValue place v dans node à l'emplacement segs, en créant les objets et
+// éléments manquants (sauf si create est faux pour le tableau node), et
+// retourne node modifié.
+func setValue(node any, segs []pathSegment, v any, create bool) (any, error) {
+	if len(segs) == 0 {
+		return v, nil
+	}
+	s, rest := segs[0], segs[1:]
+
+	if node == nil {
+		if s.wildcard || s.filter {
+			node = []any{}
+		} else {
+			node = map[string]any{}
+		}
+	}
+
+	switch n := node.(type) {
+	case map[string]any:
+		if s.wildcard || s.filter {
+			return nil, fmt.Errorf("segment %q sur un objet", segmentString(s))
+		}
+		child, err := setValue(n[s.key], rest, v, !cardArrays[s.key])
+		if err != nil {
+			return nil, err
+		}
+		n[s.key] = child
+		return n, nil
+
+	case []any:
+		// L'élément qui porte déjà une valeur, à défaut le premier correspondant.
+		idx := -1
+		for i, el := range n {
+			if !s.matches(el, i) {
+				continue
+			}
+			if idx < 0 {
+				idx = i
+			}
+			if _, ok := findValue(el, rest); ok {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			if !s.wildcard && !s.filter {
+				return nil, fmt.Errorf("indice %q hors limites (%d éléments)", s.key, len(n))
+			}
+			if !create {
+				return nil, errNoElement
+			}
+			var el any
+			if s.filter {
+				el = map[string]any{s.filterKey: s.filterValue}
+			}
+			n = append(n, el)
+			idx = len(n) - 1
+		}
+		child, err := setValue(n[idx], rest, v, true)
+		if err != nil {
+			return nil, err
+		}
+		n[idx] = child
+		return n, nil
+
+	default:
+		return nil, fmt.Errorf("segment %q sur une valeur scalaire", segmentString(s))
+	}
+}
+
+// removeValue retire de node toutes les valeurs à l'emplacement segs.
+func removeValue(node any, segs []pathSegment) any {
+	if len(segs) == 0 {
+		return nil
+	}
+	s, rest := segs[0], segs[1:]
+	switch n := node.(type) {
+	case map[string]any:
+		if s.wildcard || s.filter {
+			return n
+		}
+		if len(rest) == 0 {
+			delete(n, s.key)
+		} else if child, ok := n[s.key]; ok {
+			n[s.key] = removeValue(child, rest)
+		}
+		return n
+	case []any:
+		if len(rest) == 0 {
+			kept := n[:0:0]
+			for i, el := range n {
+				if !s.matches(el, i) {
+					kept = append(kept, el)
+				}
+			}
+			return kept
+		}
+		for i, el := range n {
+			if s.matches(el, i) {
+				n[i] = removeValue(el, rest)
+			}
+		}
+		return n
+	default:
+		return node
+	}
+}
+
+func segmentString(s pathSegment) string {
+	switch {
+	case s.wildcard:
+		return "*"
+	case s.filter:
+		return "[" + s.filterKey + "=" + s.filterValue + "]"
+	case s.pairing:
+		return "[" + s.filterKey + "=*]"
+	default:
+		return s.key
+	}
+}
+
+// orderValue décode o en valeurs JSON génériques, ses contacts annotés de
+// leur rôle (voir contactRoleKey).
+func orderValue(o Order) (any, error) {
+	raw, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	v, err := decodeJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	if doc, ok := v.(map[string]any); ok {
+		contacts, _ := doc["contacts"].([]any)
+		roles := contactRoles(o)
+		for i, c := range contacts {
+			if obj, ok := c.(map[string]any); ok && roles[i] != RoleUnknown {
+				obj[contactRoleKey] = string(roles[i])
+			}
+		}
+	}
+	return v, nil
+}
+
+// cloneJSON copie en profondeur une valeur JSON générique.
+func cloneJSON(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(x))
+		for k, child := range x {
+			c[k] = cloneJSON(child)
+		}
+		return c
+	case []any:
+		c := make([]any, len(x))
+		for i, child := range x {
+			c[i] = cloneJSON(child)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// collectValues retourne toutes les valeurs de node à l'emplacement segs.
+func collectValues(node any, segs []pathSegment) []any {
+	if len(segs) == 0 {
+		return []any{node}
+	}
+	s, rest := segs[0], segs[1:]
+	switch n := node.(type) {
+	case map[string]any:
+		if s.key != "" {
+			return collectValues(n[s.key], rest)
+		}
+	case []any:
+		var values []any
+		for i, el := range n {
+			if s.matches(el, i) {
+				values = append(values, collectValues(el, rest)...)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// expand retourne les chemins auxquels appliquer r dans doc : son chemin tel
+// quel, ou, avec un segment « [clé=*] », un chemin filtré par élément de doc
+// portant la clé.
+func (r compiledRule) expand(doc any) [][]pathSegment {
+	if r.pairing < 0 {
+		return [][]pathSegment{r.segs}
+	}
+	pair := r.segs[r.pairing]
+	var paths [][]pathSegment
+	seen := map[string]bool{}
+	for _, arr := range collectValues(doc, r.segs[:r.pairing]) {
+		elems, _ := arr.([]any)
+		for _, el := range elems {
+			obj, ok := el.(map[string]any)
+			if !ok || isEmptyJSON(obj[pair.filterKey]) {
+				continue
+			}
+			value := fmt.Sprint(obj[pair.filterKey])
+			if seen[value] {
+				continue
+			}
+			seen[value] = true
+			segs := slices.Clone(r.segs)
+			segs[r.pairing] = pathSegment{filter: true, filterKey: pair.filterKey, filterValue: value}
+			paths = append(paths, segs)
+		}
+	}
+	return paths
+}
+
+// mergeSources donne accès, décodés à la demande, à l'ancien snapshot et à la
+// réponse d'une fusion.
+type mergeSources struct {
+	master, fresh Order
+	hasOldData    bool
+
+	oldDoc, newDoc   any
+	oldDone, newDone bool
+	oldOK, newOK     bool
+}
+
+// old retourne l'ancien snapshot décodé ; false s'il n'y en a pas.
+func (m *mergeSources) old() (any, bool) {
+	if !m.oldDone {
+		m.oldDone = true
+		if m.hasOldData {
+			m.oldDoc, m.oldOK = decodeSource(m.master, "ancien snapshot")
+		}
+	}
+	return m.oldDoc, m.oldOK
+}
+
+// latest retourne la réponse décodée.
+func (m *mergeSources) latest() (any, bool) {
+	if !m.newDone {
+		m.newDone = true
+		m.newDoc, m.newOK = decodeSource(m.fresh, "réponse")
+	}
+	return m.newDoc, m.newOK
+}
+
+func decodeSource(o Order, name string) (any, bool) {
+	doc, err := orderValue(o)
+	if err != nil {
+		slog.Warn("source des règles de préservation illisible", "source", name, "error", err)
+		return nil, false
+	}
+	return doc, true
+}
+
+// find retourne la première valeur non vide de la source à l'emplacement segs.
+func find(source func() (any, bool), segs []pathSegment) (any, bool) {
+	doc, ok := source()
+	if !ok {
+		return nil, false
+	}
+	return findValue(doc, segs)
+}
+
+// applyPreservationRules réinjecte dans merged les valeurs que rules
+// conservent. L'ancien snapshot et la réponse ne sont décodés que si une
+// règle doit y lire une valeur. Une règle inapplicable est journalisée et
+// ignorée.
+func applyPreservationRules(merged Order, src *mergeSources, phase OrderPhase, rules compiledRules) Order {
+	if len(rules) == 0 {
+		return merged
+	}
+	doc, err := orderValue(merged)
+	if err != nil {
+		slog.Warn("règles de préservation ignorées", "error", err)
+		return merged
+	}
+
+	changed := false
+	for _, r := range rules {
+		for _, segs := range r.expand(doc) {
+			updated, ok, err := r.apply(doc, segs, src, phase)
+			if err != nil {
+				slog.Warn("règle de préservation ignorée", "path", r.Path, "error", err)
+				continue
+			}
+			if ok {
+				doc, changed = updated, true
+			}
+		}
+	}
+	if !changed {
+		return merged
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		slog.Warn("règles de préservation ignorées", "error", err)
+		return merged
+	}
+	var restored Order
+	if err := json.Unmarshal(raw, &restored); err != nil {
+		slog.Warn("règles de préservation ignorées", "error", err)
+		return merged
+	}
+	return restored
+}
+
+// apply applique r à doc à l'emplacement segs et indique si doc a changé.
+func (r compiledRule) apply(doc any, segs []pathSegment, src *mergeSources, phase OrderPhase) (any, bool, error) {
+	current, hasCurrent := findValue(doc, segs)
+
+	if slices.Contains(r.ClearOn, phase) {
+		if !hasCurrent {
+			return doc, false, nil
+		}
+		if _, hasNew := find(src.latest, segs); hasNew {
+			return doc, false, nil
+		}
+		return removeValue(doc, segs), true, nil
+	}
+
+	var kept any
+	var ok bool
+	switch {
+	case r.Strategy == FirstWins:
+		if kept, ok = find(src.old, segs); !ok && !hasCurrent {
+			kept, ok = find(src.latest, segs)
+		}
+	case hasCurrent:
+		return doc, false, nil
+	default:
+		if kept, ok = find(src.latest, segs); !ok {
+			kept, ok = find(src.old, segs)
+		}
+	}
+	if !ok || (hasCurrent && reflect.DeepEqual(current, kept)) {
+		return doc, false, nil
+	}
+	updated, err := setValue(doc, segs, cloneJSON(kept), true)
+	if errors.Is(err, errNoElement) {
+		return doc, false, nil
+	}
+	if err != nil {
+		return doc, false, err
+	}
+	return updated, true, nil
+}
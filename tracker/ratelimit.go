@@ -0,0 +1,184 @@
+package tracker
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// ==========================================
+// Limiteur de débit partagé (token bucket)
+// ==========================================
+
+// Seuils d'adaptation de l'intervalle de polling à la saturation du budget.
+const (
+	saturationThreshold  = 0.5
+	maxSaturatedInterval = 300
+)
+
+// bucket est un token bucket à réservation : un appel prend un jeton tout de
+// suite, quitte à passer en négatif, et attend le temps de le rembourser.
+type bucket struct {
+	rate   float64 // jetons par seconde
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// advance crédite les jetons accumulés depuis le dernier appel.
+func (b *bucket) advance(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	}
+	b.last = now
+}
+
+// reserve prend un jeton et retourne l'attente nécessaire pour l'honorer.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.advance(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// saturation retourne la part du burst déjà consommée (> 1 si des appels attendent).
+func (b *bucket) saturation(now time.Time) float64 {
+	b.advance(now)
+	return (b.burst - b.tokens) / b.burst
+}
+
+// RateLimiter plafonne le débit de requêtes vers Uber, tous workers confondus.
+// Un budget global (requêtes/seconde + burst) s'applique à chaque fetch ; un
+// budget optionnel par session (WithSessionBudget) s'y ajoute pour SessionPool.
+type RateLimiter struct {
+	mu           sync.Mutex
+	global       *bucket
+	sessionRate  float64
+	sessionBurst int
+	sessions     map[string]*bucket
+}
+
+// LimiterOption configure un RateLimiter lors de sa création.
+type LimiterOption func(*RateLimiter)
+
+// WithSessionBudget limite en plus chaque session à rps requêtes/seconde avec
+// son propre burst (voir SessionPool et WithSessionLimiter).
+func WithSessionBudget(rps float64, burst int) LimiterOption {
+	return func(l *RateLimiter) {
+		l.sessionRate = rps
+		l.sessionBurst = burst
+	}
+}
+
+// NewRateLimiter crée un limiteur de rps requêtes/seconde avec un burst maximal.
+// Des valeurs non positives sont remplacées par le budget par défaut (2 req/s, burst 5).
+func NewRateLimiter(rps float64, burst int, opts ...LimiterOption) *RateLimiter {
+	if rps <= 0 {
+		rps = defaultRequestsPerSecond
+	}
+	if burst <= 0 {
+		burst = defaultRequestBurst
+	}
+	l := &RateLimiter{
+		global:   newBucket(rps, burst),
+		sessions: make(map[string]*bucket),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Wait bloque jusqu'à ce que le budget global autorise une requête.
+// Retourne l'erreur du contexte s'il est annulé pendant l'attente.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	return l.wait(ctx, func() *bucket { return l.global })
+}
+
+// WaitSession bloque jusqu'à ce que le budget de la session name autorise une
+// requête. Sans WithSessionBudget, retourne immédiatement.
+func (l *RateLimiter) WaitSession(ctx context.Context, name string) error {
+	if l.sessionRate <= 0 {
+		return ctx.Err()
+	}
+	return l.wait(ctx, func() *bucket {
+		b, ok := l.sessions[name]
+		if !ok {
+			b = newBucket(l.sessionRate, l.sessionBurst)
+			l.sessions[name] = b
+		}
+		return b
+	})
+}
+
+// Saturation indique la pression sur le budget global : 0 quand il est plein,
+// 1 quand il est épuisé, au-delà quand des requêtes sont en file d'attente.
+func (l *RateLimiter) Saturation() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.global.saturation(time.Now())
+}
+
+// Limit retourne un FetchFn qui attend le budget global avant chaque appel à fn.
+func (l *RateLimiter) Limit(fn FetchFn) FetchFn {
+	return func(ctx context.Context, uuid string) ([]byte, error) {
+		if err := l.Wait(ctx); err != nil {
+			return nil, err
+		}
+		return fn(ctx, uuid)
+	}
+}
+
+// wait réserve un jeton dans le bucket retourné par get et attend qu'il soit
+// disponible. En cas d'annulation, le jeton est rendu.
+func (l *RateLimiter) wait(ctx context.Context, get func() *bucket) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	b := get()
+	delay := b.reserve(time.Now())
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		b.tokens = math.Min(b.burst, b.tokens+1)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// SaturatedInterval allonge un intervalle de polling (en secondes) quand le
+// budget de requêtes est saturé, pour étaler la charge au lieu de faire la queue.
+//   - saturation < 0.5 → intervalle inchangé
+//   - sinon            → intervalle × (1 + saturation), plafonné à 300 s
+func SaturatedInterval(interval int, saturation float64) int {
+	if saturation < saturationThreshold {
+		return interval
+	}
+	scaled := int(math.Round(float64(interval) * (1 + saturation)))
+	if scaled > maxSaturatedInterval {
+		return max(interval, maxSaturatedInterval)
+	}
+	return scaled
+}
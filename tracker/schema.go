@@ -0,0 +1,218 @@
+package tracker
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================================
+// Détection des dérives du format de réponse Uber
+// ==========================================
+
+// Chemins attendus dans chaque commande. Leur absence se traduit sinon
+// silencieusement par un ETA à -1 ou un texte vide.
+const (
+	SchemaPathOrderPhase = "orderInfo.orderPhase"
+	SchemaPathStatus     = "feedCards[].status"
+	SchemaPathETALabel   = "backgroundFeedCards[].mapEntity[LABEL]"
+)
+
+// SchemaReport décrit les écarts d'une réponse par rapport au format attendu.
+type SchemaReport struct {
+	Missing []string // chemins attendus absents (SchemaPath*)
+	Unknown []string // clés inconnues, ex : "data.meta", "orders[].newField"
+}
+
+// HasDrift indique si la réponse s'écarte du format attendu.
+func (r SchemaReport) HasDrift() bool {
+	return len(r.Missing) > 0 || len(r.Unknown) > 0
+}
+
+// String résume le rapport, ex : "absents: feedCards[].status; inconnus: orders[].foo".
+func (r SchemaReport) String() string {
+	var parts []string
+	if len(r.Missing) > 0 {
+		parts = append(parts, "absents: "+strings.Join(r.Missing, ", "))
+	}
+	if len(r.Unknown) > 0 {
+		parts = append(parts, "inconnus: "+strings.Join(r.Unknown, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Clés connues de chaque niveau, déduites des tags json des modèles.
+var (
+	knownRootKeys  = jsonKeys(reflect.TypeFor[Response](), "status") // "status" : enveloppe de l'API
+	knownDataKeys  = jsonKeys(reflect.TypeFor[Data]())
+	knownOrderKeys = jsonKeys(reflect.TypeFor[Order]())
+)
+
+// jsonKeys retourne les noms json des champs de t, plus extra.
+func jsonKeys(t reflect.Type, extra ...string) map[string]bool {
+	keys := make(map[string]bool, t.NumField()+len(extra))
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	for _, k := range extra {
+		keys[k] = true
+	}
+	return keys
+}
+
+// ValidateSchema compare la réponse brute au format attendu par les modèles,
+// pour la commande uuid (ou la première). Une réponse illisible n'est pas
+// analysée : fetchAndParse la signale déjà comme ErrSchema.
+func ValidateSchema(raw []byte, uuid string) SchemaReport {
+	var root map[string]json.RawMessage
+	if json.Unmarshal(raw, &root) != nil {
+		return SchemaReport{}
+	}
+	var r SchemaReport
+	r.Unknown = unknownKeys(r.Unknown, "", root, knownRootKeys)
+
+	var data map[string]json.RawMessage
+	if json.Unmarshal(root["data"], &data) != nil {
+		return r
+	}
+	r.Unknown = unknownKeys(r.Unknown, "data.", data, knownDataKeys)
+
+	var orders []map[string]json.RawMessage
+	if json.Unmarshal(data["orders"], &orders) != nil || len(orders) == 0 {
+		return r
+	}
+	order := orders[0]
+	for _, o := range orders {
+		var id string
+		if json.Unmarshal(o["uuid"], &id) == nil && id == uuid {
+			order = o
+			break
+		}
+	}
+	r.Unknown = unknownKeys(r.Unknown, "orders[].", order, knownOrderKeys)
+
+	// Les chemins attendus sont vérifiés sur la forme brute, pour ne pas
+	// confondre un champ absent et sa valeur zéro.
+	var info struct {
+		OrderPhase *string `json:"orderPhase"`
+	}
+	var cards []map[string]json.RawMessage
+	_ = json.Unmarshal(order["orderInfo"], &info)
+	_ = json.Unmarshal(order["feedCards"], &cards)
+
+	if info.OrderPhase == nil || *info.OrderPhase == "" {
+		r.Missing = append(r.Missing, SchemaPathOrderPhase)
+	}
+	if !slices.ContainsFunc(cards, func(c map[string]json.RawMessage) bool {
+		return len(c["status"]) > 0 && string(c["status"]) != "null"
+	}) {
+		r.Missing = append(r.Missing, SchemaPathStatus)
+	}
+	// Uber retire la carte (et donc l'ETA) une fois la commande terminée.
+	if info.OrderPhase == nil || !OrderPhase(*info.OrderPhase).IsTerminal() {
+		var bg []BackgroundFeedCard
+		if json.Unmarshal(order["backgroundFeedCards"], &bg) != nil || !hasETALabel(bg) {
+			r.Missing = append(r.Missing, SchemaPathETALabel)
+		}
+	}
+	return r
+}
+
+// unknownKeys ajoute à dst les clés de obj absentes de known, préfixées et triées.
+func unknownKeys(dst []string, prefix string, obj map[string]json.RawMessage, known map[string]bool) []string {
+	var found []string
+	for k := range obj {
+		if !known[k] {
+			found = append(found, prefix+k)
+		}
+	}
+	sort.Strings(found)
+	return append(dst, found...)
+}
+
+// hasETALabel indique si les cartes de fond contiennent une entité LABEL.
+func hasETALabel(cards []BackgroundFeedCard) bool {
+	for _, card := range cards {
+		for _, entity := range card.MapEntity {
+			if entity.Type == "LABEL" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ==========================================
+// Agrégation horaire
+// ==========================================
+
+// schemaRetention est le nombre d'heures conservées par un SchemaMonitor.
+const schemaRetention = 24
+
+// SchemaDriftStats agrège les rapports d'une heure.
+type SchemaDriftStats struct {
+	Hour      time.Time      // début de l'heure (UTC)
+	Responses int            // réponses analysées
+	Drifted   int            // réponses présentant au moins un écart
+	Missing   map[string]int // occurrences par chemin absent
+	Unknown   map[string]int // occurrences par clé inconnue
+}
+
+// SchemaMonitor agrège les rapports de schéma par heure, sur les dernières
+// 24 heures. Sûr pour un usage concurrent (un monitor pour tous les workers).
+type SchemaMonitor struct {
+	mu    sync.Mutex
+	hours []SchemaDriftStats // triées par heure croissante
+}
+
+// NewSchemaMonitor crée un monitor vide.
+func NewSchemaMonitor() *SchemaMonitor {
+	return &SchemaMonitor{}
+}
+
+// Record comptabilise le rapport d'une réponse reçue à at.
+func (m *SchemaMonitor) Record(r SchemaReport, at time.Time) {
+	hour := at.UTC().Truncate(time.Hour)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n := len(m.hours); n == 0 || m.hours[n-1].Hour.Before(hour) {
+		m.hours = append(m.hours, SchemaDriftStats{Hour: hour, Missing: map[string]int{}, Unknown: map[string]int{}})
+		if len(m.hours) > schemaRetention {
+			m.hours = slices.Delete(m.hours, 0, len(m.hours)-schemaRetention)
+		}
+	}
+	// Un rapport en retard est compté dans l'heure en cours.
+	bucket := &m.hours[len(m.hours)-1]
+	bucket.Responses++
+	if r.HasDrift() {
+		bucket.Drifted++
+	}
+	for _, p := range r.Missing {
+		bucket.Missing[p]++
+	}
+	for _, k := range r.Unknown {
+		bucket.Unknown[k]++
+	}
+}
+
+// Stats retourne une copie des agrégats horaires, du plus ancien au plus récent.
+func (m *SchemaMonitor) Stats() []SchemaDriftStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]SchemaDriftStats, len(m.hours))
+	for i, h := range m.hours {
+		h.Missing = maps.Clone(h.Missing)
+		h.Unknown = maps.Clone(h.Unknown)
+		out[i] = h
+	}
+	return out
+}
@@ -0,0 +1,232 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ==========================================
+// Pool de sessions (multi-comptes)
+// ==========================================
+
+// SelectionStrategy détermine la session utilisée pour la prochaine requête.
+type SelectionStrategy int
+
+const (
+	// RoundRobin alterne entre les sessions disponibles.
+	RoundRobin SelectionStrategy = iota
+	// LeastRecentlyFailed privilégie la session dont le dernier échec est le plus ancien.
+	LeastRecentlyFailed
+)
+
+// Valeurs par défaut de la quarantaine d'une session.
+const (
+	defaultQuarantineAfter = 3
+	defaultQuarantineFor   = 10 * time.Minute
+)
+
+// errAllQuarantined est retournée quand aucune session n'est utilisable.
+var errAllQuarantined = fmt.Errorf("%w: toutes les sessions sont en quarantaine", ErrUnauthorized)
+
+// SessionStats décrit la santé d'une session du pool.
+type SessionStats struct {
+	Name                string
+	Requests            int
+	Failures            int
+	ConsecutiveFailures int // refus d'identité (401/403) consécutifs
+	LastUsed            time.Time
+	LastFailure         time.Time
+	QuarantinedUntil    time.Time
+}
+
+// Quarantined indique si la session est en quarantaine à l'instant now.
+func (s SessionStats) Quarantined(now time.Time) bool {
+	return now.Before(s.QuarantinedUntil)
+}
+
+type session struct {
+	client *Client
+	stats  SessionStats
+}
+
+// SessionPool répartit les requêtes sur plusieurs sessions indépendantes
+// (cookies + client TLS propres). Une session dont l'identité est refusée à
+// répétition est mise en quarantaine et les requêtes basculent sur les autres.
+// SessionPool.Fetch satisfait FetchFn.
+type SessionPool struct {
+	mu              sync.Mutex
+	sessions        []*session
+	strategy        SelectionStrategy
+	next            int
+	quarantineAfter int
+	quarantineFor   time.Duration
+	limiter         *RateLimiter
+}
+
+// PoolOption configure un SessionPool lors de sa création.
+type PoolOption func(*SessionPool)
+
+// WithSelectionStrategy choisit la stratégie de sélection (défaut : RoundRobin).
+func WithSelectionStrategy(s SelectionStrategy) PoolOption {
+	return func(p *SessionPool) { p.strategy = s }
+}
+
+// WithQuarantine met une session en quarantaine pendant duration après after
+// refus d'identité consécutifs.
+func WithQuarantine(after int, duration time.Duration) PoolOption {
+	return func(p *SessionPool) {
+		p.quarantineAfter = after
+		p.quarantineFor = duration
+	}
+}
+
+// WithSessionLimiter applique le budget par session de l (WithSessionBudget)
+// avant chaque requête d'une session.
+func WithSessionLimiter(l *RateLimiter) PoolOption {
+	return func(p *SessionPool) { p.limiter = l }
+}
+
+// Vérification compile-time : SessionPool.Fetch satisfait FetchFn.
+var _ FetchFn = (*SessionPool)(nil).Fetch
+
+// NewSessionPool crée un pool à partir de clients déjà configurés. Les clients
+// sans nom (WithName) sont nommés "session-N".
+func NewSessionPool(clients []*Client, opts ...PoolOption) *SessionPool {
+	p := &SessionPool{
+		strategy:        RoundRobin,
+		quarantineAfter: defaultQuarantineAfter,
+		quarantineFor:   defaultQuarantineFor,
+	}
+	for i, c := range clients {
+		name := c.Name()
+		if name == "" {
+			name = fmt.Sprintf("session-%d", i)
+		}
+		p.sessions = append(p.sessions, &session{client: c, stats: SessionStats{Name: name}})
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Fetch exécute la requête sur une session choisie selon la stratégie. Si
+// l'identité de la session est refusée (ErrUnauthorized) ou limitée
+// (ErrRateLimited), la requête est retentée sur les autres sessions disponibles.
+func (p *SessionPool) Fetch(ctx context.Context, uuid string) ([]byte, error) {
+	tried := make(map[*session]bool, len(p.sessions))
+	var lastErr error
+
+	for len(tried) < len(p.sessions) {
+		s := p.pick(tried)
+		if s == nil {
+			break
+		}
+		tried[s] = true
+
+		if p.limiter != nil {
+			if err := p.limiter.WaitSession(ctx, s.stats.Name); err != nil {
+				return nil, err
+			}
+		}
+
+		data, err := s.client.Fetch(ctx, uuid)
+		p.report(s, err)
+		if err == nil {
+			return data, nil
+		}
+		lastErr = fmt.Errorf("session %s: %w", s.stats.Name, err)
+
+		if ctx.Err() != nil || !(errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrRateLimited)) {
+			return nil, lastErr
+		}
+		slog.Warn("session refusée, bascule sur une autre session", "session", s.stats.Name, "uuid", SafeTruncate(uuid, 8), "error", err)
+	}
+
+	if lastErr == nil {
+		return nil, errAllQuarantined
+	}
+	return nil, lastErr
+}
+
+// Stats retourne une copie de l'état de santé de chaque session.
+func (p *SessionPool) Stats() []SessionStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]SessionStats, 0, len(p.sessions))
+	for _, s := range p.sessions {
+		out = append(out, s.stats)
+	}
+	return out
+}
+
+// pick choisit une session disponible non encore essayée, ou nil.
+func (p *SessionPool) pick(tried map[*session]bool) *session {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	available := func(s *session) bool {
+		return !tried[s] && !s.stats.Quarantined(now)
+	}
+
+	var chosen *session
+	switch p.strategy {
+	case LeastRecentlyFailed:
+		for _, s := range p.sessions {
+			if !available(s) {
+				continue
+			}
+			if chosen == nil ||
+				s.stats.LastFailure.Before(chosen.stats.LastFailure) ||
+				(s.stats.LastFailure.Equal(chosen.stats.LastFailure) && s.stats.LastUsed.Before(chosen.stats.LastUsed)) {
+				chosen = s
+			}
+		}
+	default:
+		for i := 0; i < len(p.sessions); i++ {
+			s := p.sessions[(p.next+i)%len(p.sessions)]
+			if available(s) {
+				chosen = s
+				p.next = (p.next + i + 1) % len(p.sessions)
+				break
+			}
+		}
+	}
+
+	if chosen != nil {
+		chosen.stats.LastUsed = now
+	}
+	return chosen
+}
+
+// report met à jour la santé d'une session après une requête.
+func (p *SessionPool) report(s *session, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s.stats.Requests++
+	if err == nil {
+		s.stats.ConsecutiveFailures = 0
+		return
+	}
+
+	s.stats.Failures++
+	s.stats.LastFailure = time.Now()
+	if !errors.Is(err, ErrUnauthorized) {
+		return
+	}
+
+	s.stats.ConsecutiveFailures++
+	if p.quarantineAfter > 0 && s.stats.ConsecutiveFailures >= p.quarantineAfter {
+		s.stats.QuarantinedUntil = time.Now().Add(p.quarantineFor)
+		slog.Warn("session mise en quarantaine",
+			"session", s.stats.Name,
+			"failures", s.stats.ConsecutiveFailures,
+			"until", s.stats.QuarantinedUntil.Format(time.TimeOnly))
+	}
+}
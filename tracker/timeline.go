@@ -0,0 +1,168 @@
+package tracker
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// ==========================================
+// Chronologie d'une commande (jalons)
+// ==========================================
+
+// Milestone est une étape de la vie d'une commande.
+type Milestone string
+
+// Jalons, dans l'ordre chronologique attendu.
+const (
+	MilestonePlaced          Milestone = "PLACED"           // premier poll
+	MilestoneAccepted        Milestone = "ACCEPTED"         // commande confirmée (étape 1)
+	MilestonePreparing       Milestone = "PREPARING"        // en préparation (étape 2)
+	MilestoneCourierAssigned Milestone = "COURIER_ASSIGNED" // livreur visible ou affecté
+	MilestonePickedUp        Milestone = "PICKED_UP"        // livreur en route (avant-dernière étape)
+	MilestoneArriving        Milestone = "ARRIVING"         // ETA ≤ arrivingETA après l'enlèvement
+	MilestoneDelivered       Milestone = "DELIVERED"        // livrée
+)
+
+// milestoneOrder classe les jalons d'une Timeline.
+var milestoneOrder = []Milestone{
+	MilestonePlaced, MilestoneAccepted, MilestonePreparing, MilestoneCourierAssigned,
+	MilestonePickedUp, MilestoneArriving, MilestoneDelivered,
+}
+
+// arrivingETA est l'ETA (minutes) en dessous duquel le livreur est considéré
+// comme sur le point d'arriver.
+const arrivingETA = 2
+
+// TimelineEntry date la première observation d'un jalon, ou le poll qui l'a
+// révélé s'il a été franchi entre deux polls.
+type TimelineEntry struct {
+	Milestone Milestone `json:"milestone"`
+	At        time.Time `json:"at"`
+}
+
+// Timeline est la chronologie d'une commande, triée dans l'ordre des jalons.
+// Un jalon franchi entre deux polls sans avoir été observé y figure quand
+// même, daté du poll suivant comme les jalons atteints avec lui : les durées
+// qui le bornent peuvent alors être nulles ou sous-estimées.
+type Timeline []TimelineEntry
+
+// At retourne l'instant où m a été atteint pour la première fois, d'après
+// les polls (voir Timeline).
+func (t Timeline) At(m Milestone) (time.Time, bool) {
+	for _, e := range t {
+		if e.Milestone == m {
+			return e.At, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Between retourne la durée écoulée entre deux jalons observés.
+func (t Timeline) Between(from, to Milestone) (time.Duration, bool) {
+	start, ok1 := t.At(from)
+	end, ok2 := t.At(to)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	return end.Sub(start), true
+}
+
+// PreparationTime est la durée entre la confirmation (ou, à défaut, le premier
+// poll) et l'enlèvement par le livreur : « préparée en 12 min ».
+func (t Timeline) PreparationTime() (time.Duration, bool) {
+	if d, ok := t.Between(MilestoneAccepted, MilestonePickedUp); ok {
+		return d, true
+	}
+	return t.Between(MilestonePlaced, MilestonePickedUp)
+}
+
+// DeliveryTime est la durée entre l'enlèvement et la livraison : « livrée en 9 min ».
+func (t Timeline) DeliveryTime() (time.Duration, bool) {
+	return t.Between(MilestonePickedUp, MilestoneDelivered)
+}
+
+// TotalTime est la durée entre le premier poll et la livraison.
+func (t Timeline) TotalTime() (time.Duration, bool) {
+	return t.Between(MilestonePlaced, MilestoneDelivered)
+}
+
+// pickedUpByProgress indique si la progression de la feedCard montre la
+// commande enlevée par le livreur : avant-dernière étape atteinte.
+func pickedUpByProgress(progress, total int) bool {
+	return total > 2 && progress >= total-1
+}
+
+// milestonesReached retourne les jalons atteints d'après un résultat de
+// Reconcile. Ils se déduisent de la progression et du livreur ; les phases
+// hypothétiques (PREPARING, COURIER_ASSIGNED, EN_ROUTE) ne font qu'avancer
+// les jalons si Uber venait à les envoyer.
+func milestonesReached(r ReconcileResult) []Milestone {
+	delivered := r.Phase == PhaseDelivered || r.Phase == PhaseCompleted ||
+		(r.TotalProgress > 0 && r.Progress >= r.TotalProgress)
+	pickedUp := delivered || r.Phase == PhaseEnRoute || pickedUpByProgress(r.Progress, r.TotalProgress)
+
+	reached := []Milestone{MilestonePlaced}
+	add := func(m Milestone, ok bool) {
+		if ok {
+			reached = append(reached, m)
+		}
+	}
+	add(MilestoneAccepted, r.Progress >= 1 || r.Phase == PhasePreparing)
+	add(MilestonePreparing, r.Progress >= 2 || r.Phase == PhasePreparing)
+	add(MilestoneCourierAssigned, r.Courier != nil || r.Phase == PhaseCourierAssigned || pickedUp)
+	add(MilestonePickedUp, pickedUp)
+	add(MilestoneArriving, pickedUp && !delivered && r.Eta >= 0 && r.Eta <= arrivingETA)
+	add(MilestoneDelivered, delivered)
+	return reached
+}
+
+// Observe retourne t complétée des jalons atteints d'après r et pas encore
+// datés, à l'instant at, et indique si elle a changé. Le worker l'appelle à
+// chaque poll ; un appelant de Reconcile peut l'utiliser de la même façon.
+// Une commande annulée ou en échec ne franchit plus de jalon.
+func (t Timeline) Observe(r ReconcileResult, at time.Time) (Timeline, bool) {
+	if r.Phase == PhaseCancelled || r.Phase == PhaseFailed {
+		return t, false
+	}
+	changed := false
+	for _, m := range milestonesReached(r) {
+		if _, ok := t.At(m); !ok {
+			t = append(t, TimelineEntry{Milestone: m, At: at})
+			changed = true
+		}
+	}
+	if changed {
+		slices.SortStableFunc(t, func(a, b TimelineEntry) int {
+			return slices.Index(milestoneOrder, a.Milestone) - slices.Index(milestoneOrder, b.Milestone)
+		})
+	}
+	return t, changed
+}
+
+// loadTimeline reprend la chronologie persistée d'une commande, si le store
+// implémente TimelineStore.
+func loadTimeline(ctx context.Context, store OrderStore, uuid string) Timeline {
+	ts, ok := store.(TimelineStore)
+	if !ok {
+		return nil
+	}
+	t, err := ts.GetTimeline(ctx, uuid)
+	if err != nil {
+		slog.Warn("lecture de la chronologie échouée", "uuid", SafeTruncate(uuid, 8), "error", err)
+		return nil
+	}
+	return t
+}
+
+// saveTimeline persiste la chronologie si le store implémente TimelineStore.
+func saveTimeline(ctx context.Context, store OrderStore, uuid string, t Timeline) {
+	ts, ok := store.(TimelineStore)
+	if !ok {
+		return
+	}
+	if err := ts.SaveTimeline(ctx, uuid, t); err != nil {
+		slog.Warn("persistance de la chronologie échouée", "uuid", SafeTruncate(uuid, 8), "error", err)
+	}
+}
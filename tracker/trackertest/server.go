@@ -0,0 +1,284 @@
+// Package trackertest fournit un faux endpoint getActiveOrdersV1 pour les
+// tests d'intégration : il sert des cycles de vie de commandes scriptés,
+// vérifie le payload et les cookies, et peut simuler des erreurs 401/403/429/5xx.
+// Le vrai chemin réseau du tracker (Client, FetchUberJSON, renouvellement des
+// cookies) est ainsi exercé de bout en bout, sans accès à Internet.
+package trackertest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/superselle/ubertracker/tracker"
+)
+
+// ActiveOrdersPath est le chemin servi par le faux endpoint.
+const ActiveOrdersPath = "/_p/api/getActiveOrdersV1"
+
+// Fault est une réponse d'erreur scriptée, servie à la place d'une réponse normale.
+type Fault struct {
+	Status     int
+	RetryAfter string // header Retry-After (429), optionnel
+}
+
+// Request est une requête reçue par le serveur.
+type Request struct {
+	Locale  string
+	Cookie  string
+	Header  http.Header
+	Payload tracker.TrackingPayload
+	Status  int // statut retourné
+}
+
+// lifecycle est la suite d'états servis pour une commande.
+type lifecycle struct {
+	steps []tracker.Order
+	next  int
+}
+
+// Server est un faux getActiveOrdersV1 basé sur httptest.
+type Server struct {
+	URL string
+
+	srv        *httptest.Server
+	mu         sync.Mutex
+	orders     map[string]*lifecycle
+	faults     []Fault
+	cookie     *http.Cookie // cookie exigé, nil = aucun
+	setCookies []string
+	requests   []Request
+}
+
+// NewServer démarre un serveur sans commande. L'appelant doit appeler Close.
+func NewServer() *Server {
+	s := &Server{orders: make(map[string]*lifecycle)}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close arrête le serveur.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client retourne un tracker.Client pointant vers le serveur. Les options
+// fournies s'appliquent après WithBaseURL.
+func (s *Server) Client(opts ...tracker.ClientOption) *tracker.Client {
+	return tracker.NewClient(append([]tracker.ClientOption{tracker.WithBaseURL(s.URL)}, opts...)...)
+}
+
+// AddOrder scripte le cycle de vie de la commande uuid : chaque requête sert
+// l'étape suivante, la dernière étape est ensuite servie indéfiniment.
+// Une commande inconnue est servie comme une liste vide.
+func (s *Server) AddOrder(uuid string, steps ...tracker.Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lc := &lifecycle{}
+	for _, step := range steps {
+		step.UUID = uuid
+		lc.steps = append(lc.steps, step)
+	}
+	s.orders[uuid] = lc
+}
+
+// Fail programme des réponses d'erreur, servies dans l'ordre aux prochaines requêtes.
+func (s *Server) Fail(faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, faults...)
+}
+
+// FailNext programme n réponses d'erreur avec le statut status.
+func (s *Server) FailNext(status, n int) {
+	for i := 0; i < n; i++ {
+		s.Fail(Fault{Status: status})
+	}
+}
+
+// RequireCookie exige le cookie name=value : toute requête qui ne le porte
+// pas reçoit un 403, comme une session refusée par Uber.
+func (s *Server) RequireCookie(name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cookie = &http.Cookie{Name: name, Value: value}
+}
+
+// SetCookieOnSuccess ajoute un header Set-Cookie (valeur brute) à chaque réponse 200.
+func (s *Server) SetCookieOnSuccess(raw string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setCookies = append(s.setCookies, raw)
+}
+
+// Refresher retourne un tracker.CookieProvider qui simule le navigateur :
+// Refresh retourne le cookie exigé par RequireCookie.
+func (s *Server) Refresher() tracker.CookieProvider {
+	return &refresher{s: s}
+}
+
+// Requests retourne une copie des requêtes reçues.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Hits retourne le nombre de requêtes reçues pour la commande uuid.
+func (s *Server) Hits(uuid string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.requests {
+		if r.Payload.OrderUUID == uuid {
+			n++
+		}
+	}
+	return n
+}
+
+// Phases construit un cycle de vie minimal, une étape par phase, avec une
+// progression croissante (utilisable avec AddOrder).
+func Phases(phases ...string) []tracker.Order {
+	steps := make([]tracker.Order, 0, len(phases))
+	for i, phase := range phases {
+		steps = append(steps, tracker.Order{
+			OrderStatus: tracker.OrderInfo{OrderPhase: phase},
+			FeedCards: []tracker.FeedCard{{
+				Status: &tracker.StatusInfo{
+					Title:           phase,
+					CurrentProgress: i + 1,
+					TotalProgress:   len(phases),
+					StatusSummary:   tracker.SummaryText{Text: phase},
+				},
+			}},
+		})
+	}
+	return steps
+}
+
+// handle sert une requête getActiveOrdersV1.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		s.record(req, http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.faults) > 0 {
+		f := s.faults[0]
+		s.faults = s.faults[1:]
+		if f.RetryAfter != "" {
+			w.Header().Set("Retry-After", f.RetryAfter)
+		}
+		w.WriteHeader(f.Status)
+		s.requests = append(s.requests, withStatus(req, f.Status))
+		return
+	}
+
+	if s.cookie != nil && !hasCookie(r, s.cookie) {
+		w.WriteHeader(http.StatusForbidden)
+		s.requests = append(s.requests, withStatus(req, http.StatusForbidden))
+		return
+	}
+
+	resp := tracker.Response{}
+	if lc, ok := s.orders[req.Payload.OrderUUID]; ok && len(lc.steps) > 0 {
+		resp.Data.Orders = []tracker.Order{lc.steps[lc.next]}
+		if lc.next < len(lc.steps)-1 {
+			lc.next++
+		}
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, c := range s.setCookies {
+		w.Header().Add("Set-Cookie", c)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+	s.requests = append(s.requests, withStatus(req, http.StatusOK))
+}
+
+// record enregistre une requête invalide.
+func (s *Server) record(req Request, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, withStatus(req, status))
+}
+
+func withStatus(req Request, status int) Request {
+	req.Status = status
+	return req
+}
+
+// parseRequest valide la forme de la requête, comme le ferait l'API Uber.
+func parseRequest(r *http.Request) (Request, error) {
+	req := Request{
+		Locale: r.URL.Query().Get("localeCode"),
+		Cookie: r.Header.Get("Cookie"),
+		Header: r.Header.Clone(),
+	}
+	switch {
+	case r.Method != http.MethodPost:
+		return req, fmt.Errorf("méthode %s, POST attendu", r.Method)
+	case r.URL.Path != ActiveOrdersPath:
+		return req, fmt.Errorf("chemin inconnu: %s", r.URL.Path)
+	case req.Locale == "":
+		return req, fmt.Errorf("paramètre localeCode manquant")
+	case !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json"):
+		return req, fmt.Errorf("content-type %q, application/json attendu", r.Header.Get("Content-Type"))
+	case r.Header.Get("X-Csrf-Token") == "":
+		return req, fmt.Errorf("header x-csrf-token manquant")
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req.Payload); err != nil {
+		return req, fmt.Errorf("payload invalide: %w", err)
+	}
+	if req.Payload.OrderUUID == "" || req.Payload.Timezone == "" {
+		return req, fmt.Errorf("payload incomplet: orderUuid et timezone sont requis")
+	}
+	return req, nil
+}
+
+// hasCookie indique si la requête porte le cookie want (même nom, même valeur).
+func hasCookie(r *http.Request, want *http.Cookie) bool {
+	c, err := r.Cookie(want.Name)
+	return err == nil && c.Value == want.Value
+}
+
+// refresher simule le navigateur headless : il fournit le cookie attendu par le serveur.
+type refresher struct {
+	s *Server
+}
+
+func (f *refresher) jar() *tracker.CookieJar {
+	f.s.mu.Lock()
+	defer f.s.mu.Unlock()
+	if f.s.cookie == nil {
+		return tracker.NewCookieJar()
+	}
+	return tracker.NewCookieJar(tracker.Cookie{Name: f.s.cookie.Name, Value: f.s.cookie.Value})
+}
+
+func (f *refresher) Get(context.Context) (*tracker.CookieJar, error) { return f.jar(), nil }
+
+func (f *refresher) Refresh(context.Context, string) (*tracker.CookieJar, error) {
+	return f.jar(), nil
+}
+
+func (f *refresher) Invalidate() {}
@@ -3,9 +3,11 @@ package tracker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/rand"
+	"slices"
 	"time"
 )
 
@@ -25,39 +27,83 @@ func SafeTruncate(s string, maxLen int) string {
 // ==========================================
 
 // fetchAndParse appelle l'API Uber et désérialise la réponse.
-// Retourne la Response complète ou une erreur.
-func fetchAndParse(ctx context.Context, fetchFn FetchFn, uuid string) (Response, error) {
+// Retourne la Response complète et l'écart de la réponse brute au format attendu
+// (voir ValidateSchema), ou une erreur typée (ErrSchema, ErrOrderNotFound ou
+// l'erreur de fetchFn).
+func fetchAndParse(ctx context.Context, fetchFn FetchFn, uuid string) (Response, SchemaReport, error) {
 	jsonBytes, err := fetchFn(ctx, uuid)
 	if err != nil {
-		return Response{}, fmt.Errorf("API: %w", err)
+		return Response{}, SchemaReport{}, fmt.Errorf("API: %w", err)
 	}
 
 	var resp Response
 	if err := json.Unmarshal(jsonBytes, &resp); err != nil {
-		return Response{}, fmt.Errorf("JSON: %w", err)
+		return Response{}, SchemaReport{}, fmt.Errorf("%w: %w", ErrSchema, err)
 	}
 
 	if len(resp.Data.Orders) == 0 {
-		return Response{}, fmt.Errorf("aucune commande retournée")
+		return Response{}, SchemaReport{}, fmt.Errorf("%w: aucune commande retournée", ErrOrderNotFound)
 	}
 
-	return resp, nil
+	selectOrder(&resp, uuid)
+	return resp, ValidateSchema(jsonBytes, uuid), nil
+}
+
+// selectOrder place en tête de resp la commande uuid quand la réponse en
+// contient plusieurs (getActiveOrdersV1 liste toutes les commandes de la session).
+// Sans commande de cet UUID, la première reste suivie.
+func selectOrder(resp *Response, uuid string) {
+	if len(resp.Data.Orders) < 2 {
+		return
+	}
+	for _, o := range resp.Data.Orders {
+		if o.UUID == uuid {
+			resp.Data.Orders = []Order{o}
+			return
+		}
+	}
 }
 
 // ReconcileResult contient le résultat de la réconciliation entre ancien et nouvel état.
 type ReconcileResult struct {
 	FinalJSON  string
-	Phase      string
+	Phase      OrderPhase
 	Progress   int
 	Text       string
 	Eta        int
 	ShouldEmit bool
+	Events     []Event      // changements depuis le snapshot précédent
+	Courier    *Coordinates // position actuelle du livreur, nil si inconnue
+	Motion     CourierMotion
+	Contacts   OrderContacts // numéros masqués comme dans FinalJSON
+	// EnRoute indique si le livreur apporte la commande, déduit de sa position
+	// et de la progression (la phase reste ACTIVE jusqu'à la livraison).
+	EnRoute bool
+
+	TotalProgress int
+	// EstimatedETA est renseigné par le worker quand l'ETA d'Uber est absent
+	// ou instable (voir ETAEstimator).
+	EstimatedETA *ETAEstimate
+	// Timeline est renseignée par le worker, qui suit les jalons entre les polls.
+	Timeline Timeline
+	// Patch transforme le snapshot précédent en FinalJSON (voir HistoryStore).
+	Patch Patch
 }
 
 // Reconcile fusionne newOrder avec l'état précédent stocké via OrderStore.
 // Retourne le résultat de réconciliation avec les indicateurs
 // nécessaires pour décider si un update Discord est requis.
+// Sans historique de positions, Motion ne contient que la distance restante.
 func Reconcile(ctx context.Context, store OrderStore, uuid string, resp Response) (ReconcileResult, error) {
+	return reconcile(ctx, store, uuid, resp, nil, LocaleFrench, defaultCompiledRules, RedactNone)
+}
+
+// reconcile est l'implémentation de Reconcile. motion (optionnel) suit le
+// livreur entre les polls du worker : vitesse et immobilisation. locale est
+// la langue des réponses et rules les règles de préservation (voir
+// WithMergeRules). redaction masque les numéros des contacts dans FinalJSON,
+// donc dans le snapshot persisté et l'historique, comme dans Contacts.
+func reconcile(ctx context.Context, store OrderStore, uuid string, resp Response, motion *MotionTracker, locale LocalePack, rules compiledRules, redaction PhoneRedaction) (ReconcileResult, error) {
 	newOrder := resp.Data.Orders[0]
 	slog.Debug("données reçues", "uuid", SafeTruncate(uuid, 8), "phase", newOrder.OrderStatus.OrderPhase)
 
@@ -79,14 +125,26 @@ func Reconcile(ctx context.Context, store OrderStore, uuid string, resp Response
 		masterOrder = newOrder
 	}
 
-	// Fusion (déléguée au parser)
-	masterOrder, newPhase := MergeOrderData(masterOrder, newOrder, hasOldData)
+	// État précédent, relevé avant la fusion (qui modifie les feedCards en place).
+	before := orderFacts{eta: -1}
+	if hasOldData {
+		before = factsOf(masterOrder, OrderPhase(lastStatus), lastProgress)
+	}
 
-	// Toujours prendre les backgroundFeedCards les plus récents (ETA temps réel)
-	if len(newOrder.BackgroundFeedCards) > 0 {
-		masterOrder.BackgroundFeedCards = newOrder.BackgroundFeedCards
+	// Fusion (déléguée au parser)
+	masterOrder, newPhase := MergeOrderData(masterOrder, newOrder, hasOldData, WithMergeLocale(locale), withCompiledRules(rules))
+
+	// Une transition impossible (ex : COMPLETED → PREPARING) trahit une réponse
+	// périmée : la phase précédente est conservée.
+	if hasOldData {
+		if err := ValidateTransition(OrderPhase(lastStatus), newPhase); err != nil {
+			slog.Warn("phase incohérente ignorée", "uuid", SafeTruncate(uuid, 8), "error", err)
+			newPhase = OrderPhase(lastStatus)
+			masterOrder.OrderStatus.OrderPhase = lastStatus
+		}
 	}
 
+	masterOrder = redactOrder(masterOrder, redaction)
 	resp.Data.Orders[0] = masterOrder
 
 	finalJSON, err := json.Marshal(resp)
@@ -94,17 +152,46 @@ func Reconcile(ctx context.Context, store OrderStore, uuid string, resp Response
 		return ReconcileResult{}, fmt.Errorf("sérialisation JSON finale: %w", err)
 	}
 
-	var newProgress int
+	var newProgress, totalProgress int
 	var newText string
 	if len(masterOrder.FeedCards) > 0 && masterOrder.FeedCards[0].Status != nil {
 		newProgress = masterOrder.FeedCards[0].Status.CurrentProgress
+		totalProgress = masterOrder.FeedCards[0].Status.TotalProgress
 		newText = masterOrder.FeedCards[0].Status.StatusSummary.Text
 	}
 
+	var previousJSON []byte
+	if hasOldData {
+		previousJSON = []byte(lastJSONStr)
+	}
+	patch, err := DiffJSON(previousJSON, finalJSON)
+	if err != nil {
+		return ReconcileResult{}, fmt.Errorf("historique: %w", err)
+	}
+
 	newETA := ExtractETAFromOrder(masterOrder)
+	events := diffEvents(uuid, time.Now(), before, factsOf(masterOrder, newPhase, newProgress))
+
+	var courier, destination *Coordinates
+	if c, ok := ExtractCourierPosition(masterOrder); ok {
+		courier = &c
+	}
+	if c, ok := ExtractDestination(masterOrder); ok {
+		destination = &c
+	}
+
+	enRoute := isEnRoute(newPhase, newProgress, totalProgress, courier)
+	if motion == nil {
+		motion = NewMotionTracker(0)
+	}
+	now := time.Now()
+	courierMotion := motion.Observe(courier, destination, enRoute, now)
+	if courierMotion.StallStarted {
+		events = append(events, Event{Kind: EventCourierStalled, UUID: uuid, At: now, After: courierMotion.StillFor.Round(time.Second).String()})
+	}
 
 	shouldEmit := !hasOldData ||
-		newPhase != lastStatus ||
+		newPhase != OrderPhase(lastStatus) ||
 		newProgress != lastProgress ||
 		newText != lastText ||
 		newETA >= 0 // Toujours pousser si on a un ETA (il change souvent)
@@ -115,19 +202,29 @@ func Reconcile(ctx context.Context, store OrderStore, uuid string, resp Response
 		Progress:   newProgress,
 		Text:       newText,
 		Eta:        newETA,
-		ShouldEmit: shouldEmit,
+		ShouldEmit: shouldEmit || len(events) > 0,
+		Events:     events,
+		Courier:    courier,
+		Motion:     courierMotion,
+		Contacts:   ExtractContacts(masterOrder),
+		EnRoute:    enRoute,
+		Patch:      patch,
+
+		TotalProgress: totalProgress,
 	}, nil
 }
 
 // emitUpdate persiste l'état et envoie la mise à jour sur le channel.
-func emitUpdate(ctx context.Context, store OrderStore, id OrderIdentity, r ReconcileResult, updates chan<- TrackedOrder) error {
+// historySaved est faux si le patch n'a pas pu être ajouté à l'historique
+// alors que le snapshot a avancé.
+func emitUpdate(ctx context.Context, store OrderStore, id OrderIdentity, r ReconcileResult, trail []Position, updates chan<- TrackedOrder) (historySaved bool, err error) {
 	existingMsgID, _ := store.GetMessageID(ctx, id.UUID)
 
 	tracked := TrackedOrder{
 		UUID:         id.UUID,
 		ChannelID:    id.ChannelID,
 		GuildID:      id.GuildID,
-		LastStatus:   r.Phase,
+		LastStatus:   string(r.Phase),
 		LastUpdated:  time.Now(),
 		FullJSONData: r.FinalJSON,
 		ClientID:     id.ClientID,
@@ -136,10 +233,21 @@ func emitUpdate(ctx context.Context, store OrderStore, id OrderIdentity, r Recon
 		LastText:     r.Text,
 		MessageID:    existingMsgID,
 		ETAMinutes:   r.Eta,
+		EstimatedETA: r.EstimatedETA,
+		Timeline:     r.Timeline,
+		Contacts:     r.Contacts,
+		Motion:       r.Motion,
+		CourierTrail: trail,
+		Events:       r.Events,
 	}
 
 	if err := store.SaveOrder(ctx, tracked); err != nil {
-		return fmt.Errorf("SaveOrder: %w", err)
+		return false, fmt.Errorf("SaveOrder: %w", err)
+	}
+	historySaved = true
+	if err := appendHistory(ctx, store, id.UUID, HistoryEntry{At: tracked.LastUpdated, Patch: r.Patch}); err != nil {
+		slog.Warn("historique du snapshot non enregistré", "uuid", SafeTruncate(id.UUID, 8), "error", err)
+		historySaved = false
 	}
 
 	slog.Debug("update BDD réussi, envoi au consumer", "uuid", SafeTruncate(id.UUID, 8))
@@ -147,7 +255,7 @@ func emitUpdate(ctx context.Context, store OrderStore, id OrderIdentity, r Recon
 	case updates <- tracked:
 	case <-ctx.Done():
 	}
-	return nil
+	return historySaved, nil
 }
 
 // ==========================================
@@ -165,51 +273,211 @@ func StartOrderWorker(
 	id OrderIdentity,
 	updates chan<- TrackedOrder,
 	fetchFn FetchFn,
+) {
+	runOrderWorker(ctx, store, id, updates, fetchFn, workerConfig{})
+}
+
+// workerConfig regroupe les réglages optionnels d'un worker (voir Manager).
+type workerConfig struct {
+	// limiter, s'il est non nil, allonge l'intervalle de polling quand son budget est saturé.
+	limiter *RateLimiter
+	// stallAfter est le seuil d'immobilité d'un livreur en route (0 = défaut).
+	stallAfter time.Duration
+	// newEstimator crée l'estimateur d'ETA de la commande (nil = NewETAEstimator).
+	newEstimator func() ETAEstimator
+	// locale est le pack de langue des réponses qui ne viennent pas d'un
+	// Client (Code vide = LocaleFrench).
+	locale LocalePack
+	// schema, s'il est non nil, agrège les écarts de format des réponses.
+	schema *SchemaMonitor
+	// redaction masque les numéros des contacts (TrackedOrder.Contacts et FullJSONData).
+	redaction PhoneRedaction
+	// rules sont les règles de préservation analysées (nil = DefaultPreservationRules).
+	rules compiledRules
+	// maxMisses est le nombre de polls consécutifs sans la commande (liste vide,
+	// 404) avant abandon (0 = defaultMaxMisses).
+	maxMisses int
+}
+
+// defaultMaxMisses est le nombre de polls consécutifs sans la commande tolérés
+// par défaut : Uber renvoie parfois une liste vide juste après la commande ou
+// lors d'un incident passager.
+const defaultMaxMisses = 3
+
+// runOrderWorker est la boucle de StartOrderWorker.
+func runOrderWorker(
+	ctx context.Context,
+	store OrderStore,
+	id OrderIdentity,
+	updates chan<- TrackedOrder,
+	fetchFn FetchFn,
+	cfg workerConfig,
 ) {
 	slog.Info("worker démarré", "uuid", id.UUID)
 
+	// locale suit le pack du Client qui a fourni la dernière réponse, à défaut
+	// celui du Manager.
+	fallbackLocale := cfg.locale
+	if fallbackLocale.Code == "" {
+		fallbackLocale = LocaleFrench
+	}
+	locale := fallbackLocale
+	rules := cfg.rules
+	if rules == nil {
+		rules = defaultCompiledRules
+	}
+
 	failCount := 0
 	const maxFails = 10
+	missCount := 0 // polls consécutifs sans la commande
+	maxMisses := cfg.maxMisses
+	if maxMisses <= 0 {
+		maxMisses = defaultMaxMisses
+	}
+
+	// backoff impose une attente minimale avant le prochain scan (ex : Retry-After).
+	var backoff time.Duration
+
+	trail := newCourierTrail(ctx, store, id.UUID)
+	timeline := loadTimeline(ctx, store, id.UUID)
+	// Sans historique (nouveau suivi, ou store équipé après coup), le premier
+	// patch enregistré contient le snapshot entier.
+	historyBaseline := needsHistoryBaseline(ctx, store, id.UUID)
+	motion := NewMotionTracker(cfg.stallAfter)
+	var estimator ETAEstimator = NewETAEstimator()
+	if cfg.newEstimator != nil {
+		estimator = cfg.newEstimator()
+	}
+	estimate := ETAEstimate{Minutes: -1}
+	lastDrift := "" // dernier écart de format signalé (SchemaReport.String)
+
+	// abandon envoie l'update FAILED finale.
+	abandon := func(text string) {
+		select {
+		case updates <- TrackedOrder{
+			UUID:       id.UUID,
+			ChannelID:  id.ChannelID,
+			GuildID:    id.GuildID,
+			LastStatus: string(PhaseFailed),
+			LastText:   text,
+			Events:     []Event{{Kind: EventTrackingFailed, UUID: id.UUID, At: time.Now(), After: text}},
+		}:
+		case <-ctx.Done():
+		}
+	}
 
 	// scan effectue un cycle fetch → reconcile → emit.
 	// Retourne true si le worker doit s'arrêter (commande terminée ou échecs max).
 	scan := func() bool {
-		resp, err := fetchAndParse(ctx, fetchFn, id.UUID)
+		fetchCtx, reported := contextWithLocaleReport(ctx)
+		resp, report, err := fetchAndParse(fetchCtx, fetchFn, id.UUID)
+		locale = fallbackLocale
+		if reported.pack != nil {
+			locale = *reported.pack
+		}
 		if err != nil {
 			slog.Error("erreur worker", "uuid", SafeTruncate(id.UUID, 8), "error", err)
+
+			switch {
+			case errors.Is(err, ErrOrderNotFound):
+				// Souvent passager : abandon seulement après maxMisses absences consécutives.
+				missCount++
+				if missCount >= maxMisses {
+					slog.Error("commande introuvable, arrêt du worker", "uuid", SafeTruncate(id.UUID, 8), "misses", missCount)
+					abandon(locale.OrderNotFound)
+					return true
+				}
+			case errors.Is(err, ErrRateLimited):
+				// Pas un échec de la commande : on ralentit sans consommer le budget d'échecs.
+				backoff = defaultRetryAfter
+				if d, ok := RetryAfter(err); ok {
+					backoff = d
+				}
+				slog.Warn("limitation de débit, pause du worker", "uuid", SafeTruncate(id.UUID, 8), "retry_after", backoff)
+				return false
+			default:
+				missCount = 0
+			}
+
 			failCount++
 			if failCount >= maxFails {
 				slog.Error("arrêt définitif worker", "uuid", SafeTruncate(id.UUID, 8), "max_fails", maxFails)
-				select {
-				case updates <- TrackedOrder{
-					UUID:       id.UUID,
-					ChannelID:  id.ChannelID,
-					GuildID:    id.GuildID,
-					LastStatus: "FAILED",
-					LastText:   "Suivi abandonné après trop d'échecs.",
-				}:
-				case <-ctx.Done():
-				}
+				abandon(locale.TooManyFailures)
 				return true
 			}
 			return false
 		}
 
-		failCount = 0
+		failCount, missCount = 0, 0
 
-		result, err := Reconcile(ctx, store, id.UUID, resp)
+		result, err := reconcile(ctx, store, id.UUID, resp, motion, locale, rules, cfg.redaction)
 		if err != nil {
 			slog.Error("erreur reconcile", "uuid", SafeTruncate(id.UUID, 8), "error", err)
 			return false
 		}
 
+		// Écart au format attendu : signalé une fois, puis à chaque changement.
+		if cfg.schema != nil {
+			cfg.schema.Record(report, time.Now())
+		}
+		if drift := report.String(); drift != lastDrift {
+			if report.HasDrift() {
+				slog.Warn("format de réponse Uber inattendu", "uuid", SafeTruncate(id.UUID, 8), "drift", drift)
+				result.Events = append(result.Events, Event{
+					Kind: EventSchemaDrift, UUID: id.UUID, At: time.Now(), Before: lastDrift, After: drift,
+				})
+				result.ShouldEmit = true
+			}
+			lastDrift = drift
+		}
+
+		if result.Courier != nil && trail.record(ctx, Position{
+			Coordinates: *result.Courier,
+			At:          time.Now(),
+			Phase:       result.Phase,
+			ETA:         result.Eta,
+		}) {
+			result.ShouldEmit = true
+		}
+
+		var changed bool
+		timeline, changed = timeline.Observe(result, time.Now())
+		if changed {
+			saveTimeline(ctx, store, id.UUID, timeline)
+			result.ShouldEmit = true
+		}
+		result.Timeline = slices.Clone(timeline)
+
+		prevEstimate := estimate
+		estimate = estimator.Estimate(ETAInput{
+			At:            time.Now(),
+			UberETA:       result.Eta,
+			Phase:         result.Phase,
+			Progress:      result.Progress,
+			TotalProgress: result.TotalProgress,
+			Motion:        result.Motion,
+			EnRoute:       result.EnRoute,
+		})
+		if result.Eta < 0 || estimate.Jumping {
+			result.EstimatedETA = &estimate
+			if estimate.Minutes != prevEstimate.Minutes {
+				result.ShouldEmit = true
+			}
+		}
+
 		if result.ShouldEmit {
-			if err := emitUpdate(ctx, store, id, result, updates); err != nil {
+			if historyBaseline {
+				result.Patch = Patch{{Op: "replace", Path: "", Value: json.RawMessage(result.FinalJSON)}}
+			}
+			if historySaved, err := emitUpdate(ctx, store, id, result, trail.snapshot(), updates); err != nil {
 				slog.Error("erreur emitUpdate", "uuid", SafeTruncate(id.UUID, 8), "error", err)
+			} else {
+				// Un patch perdu rompt la chaîne : le suivant repart du snapshot entier.
+				historyBaseline = !historySaved
 			}
 		}
 
-		return result.Phase == "COMPLETED" || result.Phase == "DELIVERED" || result.Phase == "CANCELLED"
+		return result.Phase.IsTerminal()
 	}
 
 	// Scan initial
@@ -219,11 +487,18 @@ func StartOrderWorker(
 
 	// Boucle de polling avec intervalle adaptatif et support d'annulation via context
 	noChangeCount := 0
-	lastKnownETA := -1
+	lastKnownETA := estimate.Minutes
 	for {
 		interval := AdaptiveInterval(lastKnownETA, noChangeCount)
+		if cfg.limiter != nil {
+			interval = SaturatedInterval(interval, cfg.limiter.Saturation())
+		}
 		jitter := rand.Intn(21) //nolint:gosec // jitter for polling, not security-sensitive
 		sleepTime := time.Duration(interval+jitter) * time.Second
+		if backoff > sleepTime {
+			sleepTime = backoff
+		}
+		backoff = 0
 
 		select {
 		case <-ctx.Done():
@@ -238,13 +513,8 @@ func StartOrderWorker(
 		}
 
 		// Mise à jour de l'ETA connu pour le prochain cycle de polling
-		if _, _, _, lastJSON, err := store.GetSnapshot(ctx, id.UUID); err == nil {
-			var r Response
-			if json.Unmarshal([]byte(lastJSON), &r) == nil && len(r.Data.Orders) > 0 {
-				if eta := ExtractETAFromOrder(r.Data.Orders[0]); eta >= 0 {
-					lastKnownETA = eta
-				}
-			}
+		if estimate.Minutes >= 0 {
+			lastKnownETA = estimate.Minutes
 		}
 
 		if failCount == prevFails {